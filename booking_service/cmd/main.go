@@ -1,13 +1,25 @@
 package main
 
 import (
+	"booking_service/internal/calendar"
 	"booking_service/internal/config"
 	"booking_service/internal/db"
+	"booking_service/internal/events"
+	"booking_service/internal/fees"
+	"booking_service/internal/money"
+	"booking_service/internal/notifications"
+	"booking_service/internal/payments"
+	"booking_service/internal/repository"
 	"booking_service/internal/server"
+	"booking_service/internal/storage"
+	"booking_service/internal/stream"
+	"booking_service/internal/webhooks"
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // пояса апартаментов не должны зависеть от tzdata в образе
 
 	"github.com/sirupsen/logrus"
 )
@@ -46,8 +58,107 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to access SQL DB handle")
 	}
 
+	// INIT PHOTO STORAGE
+	store, err := storage.NewFromConfig(context.Background(), cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to init photo storage")
+	}
+	attachments, err := storage.NewPrivateFromConfig(context.Background(), cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to init attachment storage")
+	}
+
+	// START OUTBOX RELAY
+	publisher, err := events.NewFromConfig(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to init events publisher")
+	}
+
+	broker := stream.NewBroker()
+	notifier := notifications.NewNotifier(conn, notifications.NewFakeEmail(), notifications.NewFakePush())
+
+	sinks := events.Multi{
+		{Name: "bus", Publisher: publisher},
+		{Name: "webhooks", Publisher: webhooks.NewFanout(conn)},
+		{Name: "notifications", Publisher: notifier},
+	}
+	// потоки SSE всех экземпляров будит общая шина; без неё — только релей своего экземпляра,
+	// а события чужих релеев поток подхватывает опросом outbox
+	if np, ok := publisher.(*events.NATSPublisher); ok {
+		if _, err := np.Subscribe(broker); err != nil {
+			logrus.WithError(err).Fatal("Failed to subscribe stream broker to NATS")
+		}
+	} else {
+		sinks = append(sinks, events.Sink{Name: "stream", Publisher: broker})
+	}
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		events.NewRelay(conn, sinks, cfg.EVENTS_POLL_INTERVAL).Run(relayCtx)
+	}()
+
+	// START CHECK-IN REMINDERS
+	reminderDone := make(chan struct{})
+	go func() {
+		defer close(reminderDone)
+		notifications.NewReminder(notifier, cfg.CHECKIN_REMINDER_LEAD, 10*time.Minute).Run(relayCtx)
+	}()
+
+	// INIT FEES AND TAXES
+	rates := money.NewConverter(money.NewStaticFileSource(cfg.RATES_FILE), cfg.RATES_REFRESH)
+	rules, err := fees.Load(cfg.FEES_FILE)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load fee rules")
+	}
+	schedule := fees.NewSchedule(rules, rates)
+
+	repo := repository.NewRepository(conn, schedule)
+
+	// START REVIEW REVEAL
+	reviewsDone := make(chan struct{})
+	go func() {
+		defer close(reviewsDone)
+		publishDueReviews(relayCtx, repo, time.Hour)
+	}()
+
+	// START CALENDAR SYNC
+	calendarDone := make(chan struct{})
+	go func() {
+		defer close(calendarDone)
+		calendar.NewSyncer(repo, cfg.CALENDAR_SYNC_INTERVAL, time.Minute).Run(relayCtx)
+	}()
+
+	// START PAYMENT RECONCILIATION
+	provider, err := payments.NewFromConfig(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to init payment provider")
+	}
+	processor := payments.NewProcessor(repo, provider, cfg.PAYMENTS_COMMISSION_BPS, cfg.PAYMENTS_HOLD_TTL)
+
+	paymentsDone := make(chan struct{})
+	go func() {
+		defer close(paymentsDone)
+		processor.Run(relayCtx, time.Minute)
+	}()
+
+	// START WEBHOOK DISPATCHER
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		webhooks.NewDispatcher(conn, cfg.EVENTS_POLL_INTERVAL).Run(relayCtx)
+	}()
+
+	// START PAYOUT BATCHES
+	payoutsDone := make(chan struct{})
+	go func() {
+		defer close(payoutsDone)
+		runPayouts(relayCtx, repo, cfg.PAYOUT_RELEASE_DELAY, cfg.PAYOUT_INTERVAL)
+	}()
+
 	// INIT SERVER
-	srv := server.NewServer(conn)
+	srv := server.NewServer(conn, cfg, store, attachments, broker, processor, rates, schedule)
 	host := cfg.SERV_HOST
 	port := cfg.SERV_PORT
 
@@ -62,9 +173,66 @@ func main() {
 	logrus.Info("Shutting down server...")
 	server.GracefulShutdown(http_server, 5*time.Second)
 
+	stopRelay()
+	<-relayDone
+	<-dispatcherDone
+	<-reminderDone
+	<-reviewsDone
+	<-calendarDone
+	<-paymentsDone
+	<-payoutsDone
+	if np, ok := publisher.(*events.NATSPublisher); ok {
+		np.Close()
+	}
+
 	if err := sqlDB.Close(); err != nil {
 		logrus.WithError(err).Warn("Error closing DB conn")
 	} else {
 		logrus.Info("DB connection closed")
 	}
 }
+
+// publishDueReviews раскрывает отзывы, на которые вторая сторона не ответила за ReviewWindow
+func publishDueReviews(ctx context.Context, repo repository.Repository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := repo.PublishDueReviews(); err != nil {
+			logrus.WithError(err).Warn("Review reveal failed")
+		} else if n > 0 {
+			logrus.Infof("Published %d reviews after the review window", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runPayouts освобождает деньги по броням, где заезд был не позже releaseDelay назад,
+// и выплачивает хостам доступный остаток
+func runPayouts(ctx context.Context, repo repository.Repository, releaseDelay, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := repo.ReleaseHostFunds(time.Now().Add(-releaseDelay)); err != nil {
+			logrus.WithError(err).Warn("Releasing host funds failed")
+		} else if n > 0 {
+			logrus.Infof("Released host funds for %d bookings", n)
+		}
+
+		if _, err := repo.CreatePayouts(); err != nil {
+			logrus.WithError(err).Warn("Payout batch failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
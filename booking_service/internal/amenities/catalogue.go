@@ -0,0 +1,151 @@
+package amenities
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindBool  Kind = "bool"
+	KindInt   Kind = "int"
+	KindFloat Kind = "float"
+	KindEnum  Kind = "enum"
+)
+
+// Definition описывает одно удобство каталога: тип значения и допустимые границы
+type Definition struct {
+	Key    string   `json:"key"`
+	Kind   Kind     `json:"kind"`
+	Label  string   `json:"label"`
+	Unit   string   `json:"unit,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+var catalogue = []Definition{
+	{Key: "wifi", Kind: KindBool, Label: "Wi-Fi"},
+	{Key: "parking", Kind: KindBool, Label: "Parking"},
+	{Key: "pets_allowed", Kind: KindBool, Label: "Pets allowed"},
+	{Key: "smoking_allowed", Kind: KindBool, Label: "Smoking allowed"},
+	{Key: "kitchen", Kind: KindBool, Label: "Kitchen"},
+	{Key: "washer", Kind: KindBool, Label: "Washing machine"},
+	{Key: "air_conditioning", Kind: KindBool, Label: "Air conditioning"},
+	{Key: "heating", Kind: KindBool, Label: "Heating"},
+	{Key: "balcony", Kind: KindBool, Label: "Balcony"},
+	{Key: "elevator", Kind: KindBool, Label: "Elevator"},
+	{Key: "wheelchair_accessible", Kind: KindBool, Label: "Wheelchair accessible"},
+	{Key: "max_guests", Kind: KindInt, Label: "Maximum guests", Min: bound(1), Max: bound(50)},
+	{Key: "floor", Kind: KindInt, Label: "Floor", Min: bound(-5), Max: bound(200)},
+	{Key: "bathrooms", Kind: KindInt, Label: "Bathrooms", Min: bound(0), Max: bound(20)},
+	{Key: "floor_area", Kind: KindFloat, Label: "Floor area", Unit: "m2", Min: bound(1), Max: bound(10000)},
+	{Key: "view", Kind: KindEnum, Label: "View", Values: []string{"none", "city", "garden", "mountain", "sea", "river", "lake"}},
+}
+
+var byKey = func() map[string]Definition {
+	m := make(map[string]Definition, len(catalogue))
+	for _, d := range catalogue {
+		m[d.Key] = d
+	}
+	return m
+}()
+
+// Catalogue возвращает копию каталога для отдачи клиентам
+func Catalogue() []Definition {
+	out := make([]Definition, len(catalogue))
+	copy(out, catalogue)
+	return out
+}
+
+func Lookup(key string) (Definition, bool) {
+	d, ok := byKey[key]
+	return d, ok
+}
+
+// Parse приводит значение из JSON (или строку из старого поля info) к типу удобства
+func (d Definition) Parse(raw any) (any, error) {
+	if s, ok := raw.(string); ok {
+		return d.parseString(strings.TrimSpace(s))
+	}
+
+	switch d.Kind {
+	case KindBool:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+
+	case KindInt:
+		f, ok := raw.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return d.checkRange(f, int(f))
+
+	case KindFloat:
+		f, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return d.checkRange(f, f)
+
+	case KindEnum:
+		return nil, fmt.Errorf("must be one of: %s", strings.Join(d.Values, ", "))
+	}
+
+	return nil, fmt.Errorf("unsupported amenity kind %s", d.Kind)
+}
+
+func (d Definition) parseString(s string) (any, error) {
+	switch d.Kind {
+	case KindBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+
+	case KindInt:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return d.checkRange(float64(i), i)
+
+	case KindFloat:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return d.checkRange(f, f)
+
+	case KindEnum:
+		for _, v := range d.Values {
+			if strings.EqualFold(v, s) {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of: %s", strings.Join(d.Values, ", "))
+	}
+
+	return nil, fmt.Errorf("unsupported amenity kind %s", d.Kind)
+}
+
+func (d Definition) checkRange(f float64, value any) (any, error) {
+	if d.Min != nil && f < *d.Min {
+		return nil, fmt.Errorf("must be >= %v", *d.Min)
+	}
+	if d.Max != nil && f > *d.Max {
+		return nil, fmt.Errorf("must be <= %v", *d.Max)
+	}
+	return value, nil
+}
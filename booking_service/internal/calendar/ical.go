@@ -0,0 +1,142 @@
+package calendar
+
+import (
+	"booking_service/internal/models"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+)
+
+// UIDDomain — суффикс UID наших событий. По нему узнаются наши же брони,
+// вернувшиеся через ленту другой площадки, чтобы не считать их чужими
+const UIDDomain = "booking_service"
+
+const (
+	reservedSummary = "Reserved"
+	blockedSummary  = "Not available"
+)
+
+// Export собирает iCal-ленту апартамента: брони и импортированные блоки — события
+// на весь день с местными датами заезда и выезда. Данных гостей в ленте нет
+func Export(ap *models.Apartment, bookings []models.Booking, blocks []models.CalendarBlock, now time.Time) string {
+	cal := ics.NewCalendarFor(UIDDomain)
+	cal.SetMethod(ics.MethodPublish)
+	cal.SetXWRCalName(ap.Address)
+	cal.SetXWRTimezone(ap.TimeZone)
+
+	loc := ap.Location()
+	for i := range bookings {
+		b := &bookings[i]
+		checkIn, checkOut := b.CheckIn, b.CheckOut
+		if checkIn.IsZero() || checkOut.IsZero() {
+			checkIn, checkOut = localDate(b.TimeFrom, loc), localDate(b.TimeTo, loc)
+		}
+		addDayEvent(cal, "booking-"+b.ID, reservedSummary, checkIn, checkOut, now)
+	}
+	for i := range blocks {
+		bl := &blocks[i]
+		addDayEvent(cal, "block-"+bl.ID, blockedSummary, localDate(bl.TimeFrom, loc), localDate(bl.TimeTo, loc), now)
+	}
+
+	return cal.Serialize()
+}
+
+func addDayEvent(cal *ics.Calendar, id, summary string, from, to, now time.Time) {
+	// выезд в день заезда всё равно занимает день
+	if !to.After(from) {
+		to = from.AddDate(0, 0, 1)
+	}
+	e := cal.AddEvent(id + "@" + UIDDomain)
+	e.SetDtStampTime(now)
+	e.SetAllDayStartAt(from)
+	e.SetAllDayEndAt(to)
+	e.SetSummary(summary)
+	e.SetStatus(ics.ObjectStatusConfirmed)
+	e.SetTimeTransparency(ics.TransparencyOpaque)
+}
+
+func localDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Parse разбирает внешний календарь. Время без пояса и без TZID считается местным
+// временем апартамента (loc). Пропускаются отменённые и «прозрачные» события, события
+// без начала и наши собственные брони. Повторения (RRULE) не разворачиваются:
+// площадки бронирования их не используют
+func Parse(r io.Reader, loc *time.Location) ([]models.CalendarEvent, error) {
+	cal, err := ics.ParseCalendar(r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []models.CalendarEvent{}
+	for _, e := range cal.Events() {
+		uid := e.Id()
+		if uid == "" || strings.HasSuffix(uid, "@"+UIDDomain) {
+			continue
+		}
+		if p := e.GetProperty(ics.ComponentPropertyStatus); p != nil && strings.EqualFold(p.Value, string(ics.ObjectStatusCancelled)) {
+			continue
+		}
+		if p := e.GetProperty(ics.ComponentPropertyTransp); p != nil && strings.EqualFold(p.Value, string(ics.TransparencyTransparent)) {
+			continue
+		}
+
+		dtStart := e.GetProperty(ics.ComponentPropertyDtStart)
+		if dtStart == nil {
+			continue
+		}
+		start, allDay, err := eventTime(dtStart, loc)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: DTSTART: %w", uid, err)
+		}
+		end := start
+		if p := e.GetProperty(ics.ComponentPropertyDtEnd); p != nil {
+			if end, _, err = eventTime(p, loc); err != nil {
+				return nil, fmt.Errorf("event %s: DTEND: %w", uid, err)
+			}
+		} else if allDay {
+			// событие на весь день без DTEND длится один день (RFC 5545, 3.6.1)
+			end = start.AddDate(0, 0, 1)
+		}
+
+		summary := ""
+		if p := e.GetProperty(ics.ComponentPropertySummary); p != nil {
+			summary = p.Value
+		}
+
+		out = append(out, models.CalendarEvent{UID: uid, Summary: summary, Start: start, End: end, AllDay: allDay})
+	}
+
+	return out, nil
+}
+
+// eventTime разбирает DTSTART/DTEND. Дата без времени возвращается полуночью UTC и allDay = true
+func eventTime(p *ics.IANAProperty, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.Value)
+
+	isDate := len(value) == len("20060102")
+	if v, ok := p.ICalParameters[string(ics.ParameterValue)]; ok && len(v) > 0 && strings.EqualFold(v[0], string(ics.ValueDataTypeDate)) {
+		isDate = true
+	}
+	if isDate {
+		t, err := time.Parse("20060102", value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	if tzid, ok := p.ICalParameters[string(ics.ParameterTzid)]; ok && len(tzid) > 0 {
+		if zone, err := time.LoadLocation(tzid[0]); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
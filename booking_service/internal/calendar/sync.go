@@ -0,0 +1,117 @@
+package calendar
+
+import (
+	"booking_service/internal/models"
+	"booking_service/internal/repository"
+	"booking_service/internal/safehttp"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	batchSize = 20
+	// maxFeedSize — лента больше этого считается ошибкой, а не обрезается
+	maxFeedSize = 5 << 20
+)
+
+// Syncer периодически забирает внешние календари и заменяет ими блоки апартаментов.
+// Каждый импорт синхронизируется не чаще раза в interval; poll — как часто искать такие импорты.
+// Клиент не соединяется с непубличными адресами, даже если DNS календаря поменялся
+type Syncer struct {
+	repo     repository.Repository
+	client   *http.Client
+	interval time.Duration
+	poll     time.Duration
+}
+
+func NewSyncer(repo repository.Repository, interval, poll time.Duration) *Syncer {
+	return &Syncer{
+		repo:     repo,
+		client:   safehttp.NewClient(30 * time.Second),
+		interval: interval,
+		poll:     poll,
+	}
+}
+
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.poll)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			imports, err := s.repo.GetCalendarImportsToSync(time.Now().Add(-s.interval), batchSize)
+			if err != nil {
+				logrus.WithError(err).Warn("Calendar import lookup failed")
+				break
+			}
+			for i := range imports {
+				s.sync(ctx, &imports[i])
+			}
+			if len(imports) < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync забирает один календарь. Ошибка загрузки или разбора сохраняется в импорте,
+// а уже импортированные блоки остаются прежними до следующей удачной синхронизации
+func (s *Syncer) sync(ctx context.Context, imp *models.CalendarImport) {
+	events, err := s.fetch(ctx, imp)
+	if err != nil {
+		logrus.WithError(err).Warnf("Calendar import %s failed", imp.ID)
+		if err := s.repo.RecordCalendarSyncError(imp.ID, err.Error()); err != nil {
+			logrus.WithError(err).Warnf("Recording calendar import %s failure failed", imp.ID)
+		}
+		return
+	}
+
+	conflicts, err := s.repo.ReplaceCalendarBlocks(imp.ID, events)
+	if err != nil {
+		logrus.WithError(err).Warnf("Storing calendar import %s failed", imp.ID)
+		return
+	}
+	if len(conflicts) > 0 {
+		logrus.Warnf("Calendar import %s overlaps %d bookings of apartment %s", imp.ID, len(conflicts), imp.ApartmentID)
+	}
+}
+
+func (s *Syncer) fetch(ctx context.Context, imp *models.CalendarImport) ([]models.CalendarEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imp.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("calendar responded %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxFeedSize {
+		return nil, fmt.Errorf("calendar is larger than %d bytes", maxFeedSize)
+	}
+
+	return Parse(bytes.NewReader(body), imp.Apartment.Location())
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -13,6 +15,46 @@ type Config struct {
 
 	SERV_HOST string
 	SERV_PORT string
+
+	STORAGE_DRIVER string // local | s3
+	MEDIA_DIR      string
+	MEDIA_BASE_URL string
+	// PRIVATE_MEDIA_DIR — вложения переписки; не должен лежать внутри MEDIA_DIR
+	PRIVATE_MEDIA_DIR string
+
+	S3_ENDPOINT   string
+	S3_ACCESS_KEY string
+	S3_SECRET_KEY string
+	S3_BUCKET     string
+	S3_REGION     string
+	S3_USE_SSL    bool
+	S3_PUBLIC_URL string
+	// S3_PRIVATE_BUCKET — бакет для вложений переписки, без публичной политики чтения
+	S3_PRIVATE_BUCKET string
+
+	EVENTS_PUBLISHER     string // log | webhook | nats
+	EVENTS_WEBHOOK_URL   string
+	EVENTS_POLL_INTERVAL time.Duration
+	NATS_URL             string
+	NATS_SUBJECT_PREFIX  string
+
+	CHECKIN_REMINDER_LEAD time.Duration
+
+	RATES_FILE    string // пусто — курсы, вшитые в сборку
+	RATES_REFRESH time.Duration
+	FEES_FILE     string // пусто — сборы и налоги, вшитые в сборку
+
+	CALENDAR_SYNC_INTERVAL time.Duration // как часто перечитывать каждый внешний календарь
+
+	PAYMENTS_PROVIDER       string // fake | stripe
+	PAYMENTS_HOLD_TTL       time.Duration
+	PAYMENTS_COMMISSION_BPS int // комиссия платформы в сотых процента
+	PAYOUT_INTERVAL         time.Duration
+	PAYOUT_RELEASE_DELAY    time.Duration // сколько ждать после заезда, прежде чем деньги станут доступны хосту
+	PAYMENTS_WEBHOOK_SECRET string
+	STRIPE_API_URL          string
+	STRIPE_SECRET_KEY       string
+	STRIPE_WEBHOOK_SECRET   string
 }
 
 func LoadConfig() (*Config, error) {
@@ -24,5 +66,64 @@ func LoadConfig() (*Config, error) {
 		DB_NAME:     os.Getenv("BOOKING_DB_NAME"),
 		SERV_HOST:   os.Getenv("BOOKING_SERV_HOST"),
 		SERV_PORT:   os.Getenv("BOOKING_SERV_PORT"),
+
+		STORAGE_DRIVER:    getenv("BOOKING_STORAGE_DRIVER", "local"),
+		MEDIA_DIR:         getenv("BOOKING_MEDIA_DIR", "media"),
+		MEDIA_BASE_URL:    getenv("BOOKING_MEDIA_BASE_URL", "/media"),
+		PRIVATE_MEDIA_DIR: getenv("BOOKING_PRIVATE_MEDIA_DIR", "private_media"),
+
+		S3_ENDPOINT:       os.Getenv("BOOKING_S3_ENDPOINT"),
+		S3_ACCESS_KEY:     os.Getenv("BOOKING_S3_ACCESS_KEY"),
+		S3_SECRET_KEY:     os.Getenv("BOOKING_S3_SECRET_KEY"),
+		S3_BUCKET:         getenv("BOOKING_S3_BUCKET", "apartment-photos"),
+		S3_REGION:         os.Getenv("BOOKING_S3_REGION"),
+		S3_USE_SSL:        os.Getenv("BOOKING_S3_USE_SSL") == "true",
+		S3_PUBLIC_URL:     os.Getenv("BOOKING_S3_PUBLIC_URL"),
+		S3_PRIVATE_BUCKET: getenv("BOOKING_S3_PRIVATE_BUCKET", "message-attachments"),
+
+		EVENTS_PUBLISHER:     getenv("BOOKING_EVENTS_PUBLISHER", "log"),
+		EVENTS_WEBHOOK_URL:   os.Getenv("BOOKING_EVENTS_WEBHOOK_URL"),
+		EVENTS_POLL_INTERVAL: getduration("BOOKING_EVENTS_POLL_INTERVAL", time.Second),
+		NATS_URL:             getenv("BOOKING_NATS_URL", "nats://localhost:4222"),
+		NATS_SUBJECT_PREFIX:  getenv("BOOKING_NATS_SUBJECT_PREFIX", "booking"),
+
+		CHECKIN_REMINDER_LEAD: getduration("BOOKING_CHECKIN_REMINDER_LEAD", 24*time.Hour),
+
+		RATES_FILE:    os.Getenv("BOOKING_RATES_FILE"),
+		RATES_REFRESH: getduration("BOOKING_RATES_REFRESH", time.Hour),
+		FEES_FILE:     os.Getenv("BOOKING_FEES_FILE"),
+
+		CALENDAR_SYNC_INTERVAL: getduration("BOOKING_CALENDAR_SYNC_INTERVAL", 30*time.Minute),
+
+		PAYMENTS_PROVIDER:       getenv("BOOKING_PAYMENTS_PROVIDER", "fake"),
+		PAYMENTS_HOLD_TTL:       getduration("BOOKING_PAYMENTS_HOLD_TTL", 30*time.Minute),
+		PAYMENTS_COMMISSION_BPS: getint("BOOKING_PAYMENTS_COMMISSION_BPS", 1500),
+		PAYOUT_INTERVAL:         getduration("BOOKING_PAYOUT_INTERVAL", time.Hour),
+		PAYOUT_RELEASE_DELAY:    getduration("BOOKING_PAYOUT_RELEASE_DELAY", 24*time.Hour),
+		PAYMENTS_WEBHOOK_SECRET: os.Getenv("BOOKING_PAYMENTS_WEBHOOK_SECRET"),
+		STRIPE_API_URL:          getenv("BOOKING_STRIPE_API_URL", "https://api.stripe.com"),
+		STRIPE_SECRET_KEY:       os.Getenv("BOOKING_STRIPE_SECRET_KEY"),
+		STRIPE_WEBHOOK_SECRET:   os.Getenv("BOOKING_STRIPE_WEBHOOK_SECRET"),
 	}, nil
 }
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getduration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+func getint(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return fallback
+}
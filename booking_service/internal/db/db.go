@@ -38,18 +38,153 @@ func ConnectPostgres(cfg *config.Config) (*gorm.DB, error) {
 
 	logrus.Info("Connected to database succsessfully!")
 
+	if err := migrateTimestamps(db); err != nil {
+		return nil, err
+	}
+
 	err = db.AutoMigrate(
 		&models.Apartment{},
 		&models.ApartmentSCD4{},
 		&models.Description{},
+		&models.StayRules{},
 		&models.Booking{},
+		&models.BookingLineItem{},
+		&models.ApartmentPhoto{},
+		&models.CoHost{},
+		&models.OwnershipTransfer{},
+		&models.OutboxEvent{},
+		&models.OutboxDelivery{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Thread{},
+		&models.Message{},
+		&models.MessageAttachment{},
+		&models.ThreadRead{},
+		&models.Review{},
+		&models.Payment{},
+		&models.PaymentWebhookEvent{},
+		&models.LedgerTransaction{},
+		&models.LedgerEntry{},
+		&models.Payout{},
+		&models.BillingProfile{},
+		&models.InvoiceCounter{},
+		&models.Invoice{},
+		&models.InvoiceLine{},
+		&models.CalendarFeed{},
+		&models.CalendarImport{},
+		&models.CalendarBlock{},
+		&models.CalendarConflict{},
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("error during migration: %v", err)
 	}
 
+	// адреса, заведённые до появления структурированных полей: город — первая часть строки
+	err = db.Exec(`UPDATE apartments SET city = trim(split_part(address, ',', 1))
+		WHERE city IS NULL OR city = ''`).Error
+	if err != nil {
+		return nil, fmt.Errorf("error during address backfill: %v", err)
+	}
+
+	if err := migrateSearch(db); err != nil {
+		return nil, err
+	}
+
+	if err := backfillTimeZones(db); err != nil {
+		return nil, err
+	}
+
+	// не больше одной незавершённой передачи на апартамент
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_ownership_transfers_pending
+		ON ownership_transfers (ap_id) WHERE status = 'pending'`).Error
+	if err != nil {
+		return nil, fmt.Errorf("error during migration: %v", err)
+	}
+
+	// переписка по брони — одна; запрос до брони — один на пару (апартамент, гость)
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_message_threads_booking
+		ON message_threads (booking_id) WHERE booking_id IS NOT NULL`).Error
+	if err != nil {
+		return nil, fmt.Errorf("error during messaging migration: %v", err)
+	}
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_message_threads_inquiry
+		ON message_threads (ap_id, guest_id) WHERE booking_id IS NULL`).Error
+	if err != nil {
+		return nil, fmt.Errorf("error during messaging migration: %v", err)
+	}
+
+	// релей выбирает только неопубликованные события, поэтому индекс частичный
+	err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_outbox_events_pending
+		ON outbox_events (next_attempt_at, created_at) WHERE published_at IS NULL`).Error
+	if err != nil {
+		return nil, fmt.Errorf("error during outbox index migration: %v", err)
+	}
+
+	if err := migratePublishSeq(db); err != nil {
+		return nil, err
+	}
+
+	// вебхуки провайдера находят платёж по его ссылке; до авторизации ссылки нет
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_provider_ref
+		ON payments (provider, provider_ref) WHERE provider_ref <> ''`).Error
+	if err != nil {
+		return nil, fmt.Errorf("error during payments index migration: %v", err)
+	}
+
 	logrus.Info("All migrations applied succsessfully!")
 
 	return db, nil
 }
+
+// migratePublishSeq заводит последовательность для PublishSeq. Уже опубликованные события
+// получают свой seq, чтобы Last-Event-ID старых клиентов остался верным
+func migratePublishSeq(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// под той же блокировкой, что и релей: иначе setval мог бы выдать номер повторно
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, models.OutboxPublishLock).Error; err != nil {
+			return fmt.Errorf("error during publish sequence migration: %v", err)
+		}
+		if err := tx.Exec(`CREATE SEQUENCE IF NOT EXISTS outbox_publish_seq`).Error; err != nil {
+			return fmt.Errorf("error during publish sequence migration: %v", err)
+		}
+		if err := tx.Exec(`UPDATE outbox_events SET publish_seq = seq
+			WHERE publish_seq IS NULL AND published_at IS NOT NULL`).Error; err != nil {
+			return fmt.Errorf("error during publish sequence backfill: %v", err)
+		}
+		err := tx.Exec(`SELECT setval('outbox_publish_seq', GREATEST(
+			(SELECT COALESCE(max(publish_seq), 0) FROM outbox_events),
+			(SELECT last_value FROM outbox_publish_seq)) + 1, false)`).Error
+		if err != nil {
+			return fmt.Errorf("error during publish sequence migration: %v", err)
+		}
+		return nil
+	})
+}
+
+// runOnce выполняет одноразовую миграцию name и отмечает её в schema_migrations.
+// Параллельно стартующий экземпляр ждёт на вставке отметки и пропускает миграцию
+func runOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name text PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now())`).Error
+	if err != nil {
+		return fmt.Errorf("error during migration %s: %v", name, err)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`INSERT INTO schema_migrations (name) VALUES (?) ON CONFLICT DO NOTHING`, name)
+		if res.Error != nil {
+			return fmt.Errorf("error during migration %s: %v", name, res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := migrate(tx); err != nil {
+			return fmt.Errorf("error during migration %s: %v", name, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"booking_service/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// migrateSearch поддерживает apartments.search_vector триггерами: адрес (вес A)
+// плюс ключи и значения текущего описания и его удобств (вес B) в конфигурации языка объявления.
+// У удобств-флагов в документ попадает только ключ и только если флаг включён
+func migrateSearch(db *gorm.DB) error {
+	openEnd := models.OpenEnd.Format("2006-01-02 15:04:05Z07:00")
+	statements := []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION apartment_search_document(ap uuid, lang text, address text)
+		RETURNS tsvector LANGUAGE sql STABLE AS $$
+			SELECT setweight(to_tsvector(lang::regconfig, coalesce(address, '')), 'A') ||
+				setweight(to_tsvector(lang::regconfig, coalesce((
+					SELECT string_agg(kv.key || ' ' || kv.value, ' ')
+					FROM description d, jsonb_each_text(d."desc"::jsonb) kv
+					WHERE d.ap_id = ap AND d.valid_to = '%[1]s'
+				), '') || ' ' || coalesce((
+					SELECT string_agg(CASE jsonb_typeof(am.value) WHEN 'boolean' THEN am.key
+						ELSE am.key || ' ' || (am.value #>> '{}') END, ' ')
+					FROM description d, jsonb_each(d.amenities) am
+					WHERE d.ap_id = ap AND d.valid_to = '%[1]s' AND am.value <> 'false'::jsonb
+				), '')), 'B')
+		$$`, openEnd),
+
+		`CREATE OR REPLACE FUNCTION apartments_search_trigger() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			NEW.search_vector := apartment_search_document(NEW.id, NEW.search_lang, NEW.address);
+			RETURN NEW;
+		END
+		$$`,
+
+		`CREATE OR REPLACE FUNCTION description_search_trigger() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			UPDATE apartments
+			SET search_vector = apartment_search_document(id, search_lang, address)
+			WHERE id = NEW.ap_id;
+			RETURN NULL;
+		END
+		$$`,
+
+		`DROP TRIGGER IF EXISTS apartments_search_update ON apartments`,
+		`CREATE TRIGGER apartments_search_update BEFORE INSERT OR UPDATE OF address, search_lang
+			ON apartments FOR EACH ROW EXECUTE FUNCTION apartments_search_trigger()`,
+
+		`DROP TRIGGER IF EXISTS description_search_update ON description`,
+		`CREATE TRIGGER description_search_update AFTER INSERT OR UPDATE
+			ON description FOR EACH ROW EXECUTE FUNCTION description_search_trigger()`,
+
+		`UPDATE apartments SET search_vector = apartment_search_document(id, search_lang, address)
+			WHERE search_vector IS NULL`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("error during search migration: %v", err)
+		}
+	}
+
+	// документы, собранные до появления удобств в поиске, пересобираются один раз
+	return runOnce(db, "search_document_amenities", func(tx *gorm.DB) error {
+		return tx.Exec(`UPDATE apartments SET search_vector = apartment_search_document(id, search_lang, address)`).Error
+	})
+}
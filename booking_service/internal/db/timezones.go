@@ -0,0 +1,85 @@
+package db
+
+import (
+	"booking_service/internal/geocoding"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// timestampColumns — колонки, созданные как timestamp without time zone. Сервис работает в UTC,
+// поэтому значения в них считаются временем UTC
+var timestampColumns = []struct{ table, column string }{
+	{"apartments", "updated_at"},
+	{"apartments", "archived_at"},
+	{"bookings", "time_from"},
+	{"bookings", "time_to"},
+	{"description", "valid_from"},
+	{"description", "valid_to"},
+	{"invoices", "time_from"},
+	{"invoices", "time_to"},
+}
+
+// migrateTimestamps переводит колонки в timestamptz до AutoMigrate: сам AutoMigrate сменил бы тип
+// неявным приведением, которое зависит от часового пояса сессии
+func migrateTimestamps(db *gorm.DB) error {
+	for _, c := range timestampColumns {
+		var dataType string
+		if err := db.Raw(`SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`, c.table, c.column).
+			Scan(&dataType).Error; err != nil {
+			return fmt.Errorf("error during timestamptz migration: %v", err)
+		}
+		if dataType != "timestamp without time zone" {
+			continue
+		}
+
+		// старые значения читаются как время UTC, независимо от пояса сессии
+		err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE timestamptz USING %s AT TIME ZONE 'UTC'`,
+			c.table, c.column, c.column)).Error
+		if err != nil {
+			return fmt.Errorf("error during timestamptz migration of %s.%s: %v", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// backfillTimeZones задаёт пояс апартаментам известных городов и местные даты броням,
+// созданным до их появления. Пояс проставляется один раз, сразу после появления колонки:
+// позже UTC у апартамента — выбор хоста, а не отсутствие пояса
+func backfillTimeZones(db *gorm.DB) error {
+	err := runOnce(db, "apartment_time_zones", func(tx *gorm.DB) error {
+		var moved []string
+		for city, zone := range geocoding.CityZones() {
+			var ids []string
+			if err := tx.Raw(`UPDATE apartments SET time_zone = ? WHERE time_zone = 'UTC' AND lower(trim(city)) = ?
+				RETURNING id`, zone, city).Scan(&ids).Error; err != nil {
+				return err
+			}
+			moved = append(moved, ids...)
+		}
+		if len(moved) == 0 {
+			return nil
+		}
+
+		// местные даты броней этих апартаментов считались по UTC
+		return tx.Exec(`UPDATE bookings b
+			SET check_in = (b.time_from AT TIME ZONE a.time_zone)::date,
+				check_out = (b.time_to AT TIME ZONE a.time_zone)::date
+			FROM apartments a
+			WHERE a.id = b.ap_id AND b.ap_id IN ?`, moved).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`UPDATE bookings b
+		SET check_in = (b.time_from AT TIME ZONE a.time_zone)::date,
+			check_out = (b.time_to AT TIME ZONE a.time_zone)::date
+		FROM apartments a
+		WHERE a.id = b.ap_id AND b.check_in IS NULL`).Error
+	if err != nil {
+		return fmt.Errorf("error during booking dates backfill: %v", err)
+	}
+	return nil
+}
@@ -1,39 +1,241 @@
 package dtos
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // ApartmentCreateDTO используется при создании нового апартамента
 type ApartmentCreateDTO struct {
 	OwnerID      string            `json:"owner_id" binding:"required,uuid4"`
-	Address      string            `json:"address" binding:"required"`
-	Price        float64           `json:"price" binding:"required,gt=0"`
+	Address      string            `json:"address" binding:"required_without=Location"`
+	Location     *AddressDTO       `json:"location" binding:"omitempty"`
+	Language     string            `json:"language" binding:"omitempty,len=2"`
+	Status       string            `json:"status" binding:"omitempty,oneof=draft published unlisted"`
+	Price        *decimal.Decimal  `json:"price" binding:"required"`
+	Currency     string            `json:"currency" binding:"omitempty,len=3"`
+	TimeZone     string            `json:"time_zone"` // IANA, например Europe/Budapest; по умолчанию — по городу
+	CleaningFee  *decimal.Decimal  `json:"cleaning_fee"`
 	Info         map[string]string `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
+	Amenities    map[string]any    `json:"amenities"`
+}
+
+// AddressDTO — структурированный адрес; координаты можно не указывать, тогда их найдёт геокодер.
+// required_with стоит перед omitempty: иначе одна координата без другой проходила бы проверку
+type AddressDTO struct {
+	Country    string   `json:"country"`
+	City       string   `json:"city" binding:"required"`
+	Street     string   `json:"street" binding:"required"`
+	PostalCode string   `json:"postal_code"`
+	Lat        *float64 `json:"lat" binding:"required_with=Lng,omitempty,min=-90,max=90"`
+	Lng        *float64 `json:"lng" binding:"required_with=Lat,omitempty,min=-180,max=180"`
 }
 
 // ApartmentUpdateDTO — dto обновления для unmarshall
 type ApartmentUpdateDTO struct {
 	OwnerID      string             `json:"owner_id" binding:"required,uuid4"`
-	Price        *float64           `json:"price" binding:"required,gt=0"`
+	Price        *decimal.Decimal   `json:"price" binding:"required"`
+	CleaningFee  *decimal.Decimal   `json:"cleaning_fee"` // nil — без изменений
+	TimeZone     string             `json:"time_zone"`    // пусто — без изменений
 	Info         *map[string]string `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
+	Amenities    map[string]any     `json:"amenities"`
 }
 
 // ApartmentLightUpdateDTO — лёгкое обновление без изменения описаний
 type ApartmentLightUpdateDTO struct {
 	OwnerID      string     `json:"owner_id" binding:"required,uuid4"`
-	Price        *float64   `json:"price" binding:"required,gt=0"`
+	Price        *decimal.Decimal `json:"price" binding:"required"`
+	CleaningFee  *decimal.Decimal `json:"cleaning_fee"`
+	TimeZone     string           `json:"time_zone"`
 }
 
-// ApartmentHeavyUpdateDTO — обновление с изменением описаний (Info)
+// ApartmentHeavyUpdateDTO — обновление с изменением описаний (Info и/или Amenities);
+// не переданная часть переносится из текущей версии описания
 type ApartmentHeavyUpdateDTO struct {
 	OwnerID      string            `json:"owner_id" binding:"required,uuid4"`
-	Price        *float64          `json:"price" binding:"required,gt=0"`
-	Info         map[string]string `json:"info" binding:"required,dive,keys,required,endkeys,required"`
+	Price        *decimal.Decimal  `json:"price" binding:"required"`
+	CleaningFee  *decimal.Decimal  `json:"cleaning_fee"`
+	TimeZone     string            `json:"time_zone"`
+	Info         map[string]string `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
+	Amenities    map[string]any    `json:"amenities"`
+}
+
+// StayRulesDTO — правила проживания; заменяют текущие целиком. Дни — "mon".."sun", время — "15:00".
+// Пустые списки и нули не ограничивают, min_nights по умолчанию 1
+type StayRulesDTO struct {
+	OwnerID        string   `json:"owner_id" binding:"required,uuid4"`
+	MinNights      int      `json:"min_nights" binding:"min=0,max=365"`
+	MaxNights      int      `json:"max_nights" binding:"min=0,max=365"`
+	CheckInDays    []string `json:"check_in_days" binding:"max=7"`
+	CheckOutDays   []string `json:"check_out_days" binding:"max=7"`
+	CheckInTime    string   `json:"check_in_time"`
+	CheckOutTime   string   `json:"check_out_time"`
+	MinNoticeHours int      `json:"min_notice_hours" binding:"min=0,max=720"`
+	MaxHorizonDays int      `json:"max_horizon_days" binding:"min=0,max=1095"`
 }
 
 // BookingCreateDTO — создание бронирования
 type BookingCreateDTO struct {
 	UserID      string    `json:"user_id" binding:"required,uuid4"`
 	ApartmentID string    `json:"apartment_id" binding:"required,uuid4"`
-	TimeFrom    time.Time `json:"time_from" binding:"required"`
-	TimeTo      time.Time `json:"time_to" binding:"required,gtfield=TimeFrom"`
+	StayDTO
+	GuestsDTO
+	// PaymentMethod — токен способа оплаты у провайдера (для fake: pm_card_ok, pm_card_declined, pm_card_async)
+	PaymentMethod string `json:"payment_method"`
+}
+
+// StayDTO — сроки проживания: либо местные даты заезда и выезда апартамента (время берётся
+// из правил проживания), либо точные моменты time_from/time_to в RFC 3339
+type StayDTO struct {
+	CheckIn  string    `json:"check_in" form:"check_in" binding:"omitempty,datetime=2006-01-02"`
+	CheckOut string    `json:"check_out" form:"check_out" binding:"omitempty,datetime=2006-01-02"`
+	TimeFrom time.Time `json:"time_from" form:"time_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	TimeTo   time.Time `json:"time_to" form:"time_to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// GuestsDTO — состав гостей; без adults считается один взрослый
+type GuestsDTO struct {
+	Adults   int `json:"adults" form:"adults" binding:"omitempty,min=1,max=50"`
+	Children int `json:"children" form:"children" binding:"min=0,max=50"`
+	Infants  int `json:"infants" form:"infants" binding:"min=0,max=10"`
+	Pets     int `json:"pets" form:"pets" binding:"min=0,max=10"`
+}
+
+// PageRequest — параметры keyset-пагинации списков
+type PageRequest struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+// OwnerActionDTO — тело запросов, где нужен только владелец (обложка, удаление фото)
+type OwnerActionDTO struct {
+	OwnerID string `json:"owner_id" binding:"required,uuid4"`
+}
+
+// PhotoOrderDTO — новый порядок всех фотографий апартамента
+type PhotoOrderDTO struct {
+	OwnerID  string   `json:"owner_id" binding:"required,uuid4"`
+	PhotoIDs []string `json:"photo_ids" binding:"required,min=1,dive,uuid4"`
+}
+
+// HistoryQuery — необязательный интервал для истории апартамента (RFC 3339)
+type HistoryQuery struct {
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// ApartmentStatusDTO — смена статуса объявления владельцем
+type ApartmentStatusDTO struct {
+	OwnerID string `json:"owner_id" binding:"required,uuid4"`
+	Status  string `json:"status" binding:"required,oneof=draft published unlisted archived"`
+}
+
+// TransferCreateDTO — владелец предлагает передать объявление другому пользователю
+type TransferCreateDTO struct {
+	OwnerID  string `json:"owner_id" binding:"required,uuid4"`
+	ToUserID string `json:"to_user_id" binding:"required,uuid4,nefield=OwnerID"`
+}
+
+// UserActionDTO — тело запросов, где действует произвольный пользователь (принять/отклонить передачу)
+type UserActionDTO struct {
+	UserID string `json:"user_id" binding:"required,uuid4"`
+}
+
+// CoHostDTO — полный набор прав соавтора; пустой список оставляет соавтора без прав
+type CoHostDTO struct {
+	OwnerID     string   `json:"owner_id" binding:"required,uuid4"`
+	Permissions []string `json:"permissions" binding:"dive,oneof=edit_pricing edit_listing manage_bookings"`
+}
+
+// WebhookCreateDTO — подписка на события своих апартаментов; без secret сервер сгенерирует его сам
+type WebhookCreateDTO struct {
+	OwnerID    string   `json:"owner_id" binding:"required,uuid4"`
+	URL        string   `json:"url" binding:"required,http_url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=apartment.created apartment.updated apartment.status_changed apartment.owner_changed booking.created booking.confirmed booking.payment_failed booking.cancelled calendar.conflict"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=128"`
+}
+
+// WebhookUpdateDTO — частичное изменение подписки; active=true снова включает отключённый endpoint
+type WebhookUpdateDTO struct {
+	OwnerID    string   `json:"owner_id" binding:"required,uuid4"`
+	URL        *string  `json:"url" binding:"omitempty,http_url"`
+	EventTypes []string `json:"event_types" binding:"omitempty,min=1,dive,oneof=apartment.created apartment.updated apartment.status_changed apartment.owner_changed booking.created booking.confirmed booking.payment_failed booking.cancelled calendar.conflict"`
+	Active     *bool    `json:"active"`
+}
+
+// NotificationPreferencesDTO — язык и включённые каналы; не переданные поля не меняются
+type NotificationPreferencesDTO struct {
+	Locale *string `json:"locale" binding:"omitempty,len=2"`
+	Email  *bool   `json:"email"`
+	Push   *bool   `json:"push"`
+	InApp  *bool   `json:"in_app"`
+}
+
+// NotificationQuery — фильтр ленты уведомлений
+type NotificationQuery struct {
+	PageRequest
+	Unread bool `form:"unread"`
+}
+
+// InquiryDTO — вопрос гостя по апартаменту до бронирования
+type InquiryDTO struct {
+	UserID string `json:"user_id" binding:"required,uuid4"`
+	Body   string `json:"body" binding:"required,max=5000"`
+}
+
+// MessageDTO — JSON или multipart-форма; во втором случае файлы идут в поле attachments
+type MessageDTO struct {
+	UserID string `json:"user_id" form:"user_id" binding:"required,uuid4"`
+	Body   string `json:"body" form:"body" binding:"max=5000"`
+}
+
+// ReviewCreateDTO — отзыв по брони; категории обязательны только для гостя
+type ReviewCreateDTO struct {
+	UserID     string         `json:"user_id" binding:"required,uuid4"`
+	Overall    int            `json:"overall" binding:"required,min=1,max=5"`
+	Categories map[string]int `json:"categories"`
+	Comment    string         `json:"comment" binding:"max=5000"`
+}
+
+// QuoteQuery — расчёт стоимости проживания; currency — валюта, в которой показать сумму
+type QuoteQuery struct {
+	StayDTO
+	Currency string `form:"currency" binding:"omitempty,len=3"`
+	GuestsDTO
+}
+
+// BillingProfileDTO — реквизиты для счетов; заменяют сохранённые целиком
+type BillingProfileDTO struct {
+	Name    string `json:"name" binding:"max=200"`
+	Company string `json:"company" binding:"max=200"`
+	TaxID   string `json:"tax_id" binding:"max=50"`
+	Address string `json:"address" binding:"max=500"`
+}
+
+// PaymentUpdateDTO — новое состояние платежа от провайдера. Платёж ищется по PaymentID,
+// а если он пуст — по (Provider, ProviderRef). EventID задан для вебхуков и защищает от повторов
+type PaymentUpdateDTO struct {
+	PaymentID   string
+	Provider    string
+	ProviderRef string
+	Status      string
+	Reason      string
+	EventID     string
+}
+
+// CalendarImportDTO — внешний iCal-календарь апартамента (ссылка экспорта другой площадки)
+type CalendarImportDTO struct {
+	OwnerID string `json:"owner_id" binding:"required,uuid4"`
+	URL     string `json:"url" binding:"required,http_url,max=2048"`
+	Name    string `json:"name" binding:"max=100"`
+}
+
+// ApartmentImportRow — строка пакетного импорта апартаментов. Err — ошибка разбора или проверки
+// строки, ApartmentID заполняется после создания
+type ApartmentImportRow struct {
+	Line        int
+	DTO         ApartmentCreateDTO
+	Err         error
+	ApartmentID string
 }
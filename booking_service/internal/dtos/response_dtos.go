@@ -1,34 +1,80 @@
 package dtos
 
-import "time"
+import (
+	servererrors "booking_service/internal/server_errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type BookingRange struct {
 	From time.Time `json:"from" gorm:"column:from"`
 	To   time.Time `json:"to" gorm:"column:to"`
 }
 
+type LocationResponse struct {
+	Country    string   `json:"country,omitempty"`
+	City       string   `json:"city"`
+	Street     string   `json:"street,omitempty"`
+	PostalCode string   `json:"postal_code,omitempty"`
+	Lat        *float64 `json:"lat"`
+	Lng        *float64 `json:"lng"`
+	TimeZone   string   `json:"time_zone"`
+}
+
+// RatingResponse — средняя оценка по опубликованным отзывам гостей
+// MoneyResponse — сумма строкой, чтобы клиенты не теряли точность
+type MoneyResponse struct {
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+type RatingResponse struct {
+	Average *float64 `json:"average"`
+	Count   int      `json:"count"`
+}
+
 type ShortApartmentResponse struct {
-	Id      string  `json:"id" binding:"required,uuid4"`
-	OwnerID string  `json:"owner_id" binding:"required,uuid4"`
-	Address string  `json:"address" binding:"required"`
-	Price   float64 `json:"price" binding:"required,gt=0"`
+	Id       string           `json:"id" binding:"required,uuid4"`
+	OwnerID  string           `json:"owner_id" binding:"required,uuid4"`
+	Address  string           `json:"address" binding:"required"`
+	Location LocationResponse `json:"location"`
+	Price    decimal.Decimal  `json:"price"`
+	Currency string           `json:"currency"`
+	Display  *MoneyResponse   `json:"display_price,omitempty"`
+	Status   string           `json:"status"`
+	Rating   RatingResponse   `json:"rating"`
 }
 
 type MediumApartmentResponse struct {
-	Id      string            `json:"id" binding:"required,uuid4"`
-	OwnerID string            `json:"owner_id" binding:"required,uuid4"`
-	Address string            `json:"address" binding:"required"`
-	Price   float64           `json:"price" binding:"required,gt=0"`
-	Info    map[string]string `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
+	Id          string            `json:"id" binding:"required,uuid4"`
+	OwnerID     string            `json:"owner_id" binding:"required,uuid4"`
+	Address     string            `json:"address" binding:"required"`
+	Location    LocationResponse  `json:"location"`
+	Price       decimal.Decimal   `json:"price"`
+	Currency    string            `json:"currency"`
+	CleaningFee decimal.Decimal   `json:"cleaning_fee"`
+	Display     *MoneyResponse    `json:"display_price,omitempty"`
+	Status      string            `json:"status"`
+	Rating      RatingResponse    `json:"rating"`
+	Info        map[string]string `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
+	Amenities   map[string]any    `json:"amenities"`
+	Photos      []PhotoResponse   `json:"photos"`
 }
 
 type FullApartmentResponse struct {
-	Id       string                 `json:"id" binding:"required,uuid4"`
-	OwnerID  string                 `json:"owner_id" binding:"required,uuid4"`
-	Address  string                 `json:"address" binding:"required"`
-	Price    float64                `json:"price" binding:"required,gt=0"`
-	Info     map[string]string      `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
-	Bookings []ShortBookingResponse `json:"bookings"`
+	Id        string                 `json:"id" binding:"required,uuid4"`
+	OwnerID   string                 `json:"owner_id" binding:"required,uuid4"`
+	Address   string                 `json:"address" binding:"required"`
+	Location  LocationResponse       `json:"location"`
+	Price     decimal.Decimal        `json:"price"`
+	Currency  string                 `json:"currency"`
+	Status    string                 `json:"status"`
+	Rating    RatingResponse         `json:"rating"`
+	Info      map[string]string      `json:"info" binding:"omitempty,dive,keys,required,endkeys,required"`
+	Amenities map[string]any         `json:"amenities"`
+	Photos    []PhotoResponse        `json:"photos"`
+	Bookings  []ShortBookingResponse `json:"bookings"`
 }
 
 type ShortBookingResponse struct {
@@ -39,10 +85,328 @@ type ShortBookingResponse struct {
 }
 
 type BookingResponse struct {
-	Id          string    `json:"id" binding:"required,uuid4"`
-	UserID      string    `json:"user_id" binding:"required,uuid4"`
-	ApartmentID string    `json:"ap_id" binding:"required,uuid4"`
-	Address     string    `json:"address" binding:"required"`
-	TimeFrom    time.Time `json:"time_from" binding:"required"`
-	TimeTo      time.Time `json:"time_to" binding:"required,gtfield=TimeFrom"`
+	Id          string         `json:"id" binding:"required,uuid4"`
+	UserID      string         `json:"user_id" binding:"required,uuid4"`
+	ApartmentID string         `json:"ap_id" binding:"required,uuid4"`
+	Address     string         `json:"address" binding:"required"`
+	TimeFrom    time.Time      `json:"time_from" binding:"required"`
+	TimeTo      time.Time      `json:"time_to" binding:"required,gtfield=TimeFrom"`
+	CheckIn     string         `json:"check_in,omitempty"` // местные даты апартамента
+	CheckOut    string         `json:"check_out,omitempty"`
+	Status      string         `json:"status"`
+	Guests      GuestsResponse `json:"guests"`
+
+	Payment   *PaymentResponse   `json:"payment,omitempty"`
+	LineItems []LineItemResponse `json:"line_items,omitempty"`
+}
+
+type GuestsResponse struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+	Infants  int `json:"infants"`
+	Pets     int `json:"pets"`
+}
+
+type StayRulesResponse struct {
+	ApartmentID    string   `json:"apartment_id"`
+	MinNights      int      `json:"min_nights"`
+	MaxNights      int      `json:"max_nights"`
+	CheckInDays    []string `json:"check_in_days"`
+	CheckOutDays   []string `json:"check_out_days"`
+	CheckInTime    string   `json:"check_in_time"`
+	CheckOutTime   string   `json:"check_out_time"`
+	MinNoticeHours int      `json:"min_notice_hours"`
+	MaxHorizonDays int      `json:"max_horizon_days"`
+}
+
+// LineItemResponse — строка стоимости в валюте объявления
+type LineItemResponse struct {
+	Kind        string          `json:"kind"`
+	Description string          `json:"description"`
+	Quantity    int             `json:"quantity"`
+	UnitAmount  decimal.Decimal `json:"unit_amount"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// PaymentResponse: ClientSecret передаётся клиенту, когда платёж ждёт его подтверждения (3-D Secure)
+type PaymentResponse struct {
+	Id             string `json:"id"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	Currency       string `json:"currency"`
+	RefundedAmount int64  `json:"refunded_amount,omitempty"`
+	FailureReason  string `json:"failure_reason,omitempty"`
+	ClientSecret   string `json:"client_secret,omitempty"`
+}
+
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+type PhotoResponse struct {
+	Id         string            `json:"id"`
+	Position   int               `json:"position"`
+	IsCover    bool              `json:"is_cover"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Url        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+// HistoryEntry — период действия цены (type=price) или версии описания (type=description);
+// valid_to = null у действующей сейчас записи
+type HistoryEntry struct {
+	Type      string            `json:"type"`
+	ValidFrom time.Time         `json:"valid_from"`
+	ValidTo   *time.Time        `json:"valid_to"`
+	Price     *decimal.Decimal  `json:"price,omitempty"`
+	Info      map[string]string `json:"info,omitempty"`
+	Amenities map[string]any    `json:"amenities,omitempty"`
+}
+
+type TransferResponse struct {
+	Id          string     `json:"id"`
+	ApartmentID string     `json:"apartment_id"`
+	FromUserID  string     `json:"from_user_id"`
+	ToUserID    string     `json:"to_user_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy  *string    `json:"resolved_by,omitempty"`
+}
+
+type CoHostResponse struct {
+	UserID      string    `json:"user_id"`
+	Permissions []string  `json:"permissions"`
+	GrantedBy   string    `json:"granted_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookResponse — secret отдаётся только при создании подписки
+type WebhookResponse struct {
+	Id                  string     `json:"id"`
+	OwnerID             string     `json:"owner_id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"event_types"`
+	Secret              string     `json:"secret,omitempty"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	Id             string     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type NotificationResponse struct {
+	Id        string     `json:"id"`
+	Template  string     `json:"template"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+type NotificationPreferencesResponse struct {
+	UserID string `json:"user_id"`
+	Locale string `json:"locale"`
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+	InApp  bool   `json:"in_app"`
+}
+
+type ThreadResponse struct {
+	Id            string    `json:"id"`
+	ApartmentID   string    `json:"apartment_id"`
+	BookingID     *string   `json:"booking_id,omitempty"`
+	GuestID       string    `json:"guest_id"`
+	HostID        string    `json:"host_id"`
+	Unread        int64     `json:"unread"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+type AttachmentResponse struct {
+	Id          string `json:"id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Url         string `json:"url"`
+}
+
+// MessageResponse — Read: второй участник открыл переписку после этого сообщения
+type MessageResponse struct {
+	Id          string               `json:"id"`
+	SenderID    string               `json:"sender_id"`
+	Body        string               `json:"body"`
+	Attachments []AttachmentResponse `json:"attachments"`
+	Read        bool                 `json:"read"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+type ReviewResponse struct {
+	Id          string         `json:"id"`
+	BookingID   string         `json:"booking_id"`
+	Kind        string         `json:"kind"`
+	AuthorID    string         `json:"author_id"`
+	SubjectID   string         `json:"subject_id"`
+	Overall     int            `json:"overall"`
+	Categories  map[string]int `json:"categories,omitempty"`
+	Comment     string         `json:"comment,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	PublishedAt *time.Time     `json:"published_at"`
+}
+
+// BalanceResponse — остатки хоста в одной валюте, в минимальных единицах:
+// pending ждёт заезда, available уйдёт в ближайшую выплату
+type BalanceResponse struct {
+	Currency  string `json:"currency"`
+	Pending   int64  `json:"pending"`
+	Available int64  `json:"available"`
+	PaidOut   int64  `json:"paid_out"`
+}
+
+type PayoutResponse struct {
+	Id        string    `json:"id"`
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuoteResponse — стоимость в валюте объявления; display — она же в запрошенной валюте по курсу rate
+type QuoteResponse struct {
+	ApartmentID   string             `json:"apartment_id"`
+	TimeFrom      time.Time          `json:"time_from"`
+	TimeTo        time.Time          `json:"time_to"`
+	CheckIn       string             `json:"check_in"`
+	CheckOut      string             `json:"check_out"`
+	TimeZone      string             `json:"time_zone"`
+	Nights        int                `json:"nights"`
+	Guests        GuestsResponse     `json:"guests"`
+	Currency      string             `json:"currency"`
+	PricePerNight decimal.Decimal    `json:"price_per_night"`
+	Lines         []LineItemResponse `json:"lines"`
+	Total         decimal.Decimal    `json:"total"`
+	Display       *QuoteDisplay      `json:"display,omitempty"`
+}
+
+type QuoteDisplay struct {
+	Currency      string          `json:"currency"`
+	Rate          decimal.Decimal `json:"rate"`
+	PricePerNight decimal.Decimal `json:"price_per_night"`
+	Total         decimal.Decimal `json:"total"`
+}
+
+type BillingDetailsResponse struct {
+	UserID  string `json:"user_id"`
+	Name    string `json:"name"`
+	Company string `json:"company,omitempty"`
+	TaxID   string `json:"tax_id,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+type InvoiceLineResponse struct {
+	Kind        string          `json:"kind"`
+	Description string          `json:"description"`
+	Quantity    int             `json:"quantity"`
+	UnitPrice   decimal.Decimal `json:"unit_price"`
+	Amount      decimal.Decimal `json:"amount"`
+	TaxRate     decimal.Decimal `json:"tax_rate"`
+	TaxAmount   decimal.Decimal `json:"tax_amount"`
+}
+
+// InvoiceResponse — total = subtotal + tax_total; строки city_tax входят в tax_total целиком,
+// у остальных строк в tax_total идёт tax_amount
+type InvoiceResponse struct {
+	Id              string                 `json:"id"`
+	Number          string                 `json:"number"`
+	IssuedAt        time.Time              `json:"issued_at"`
+	BookingID       string                 `json:"booking_id"`
+	Guest           BillingDetailsResponse `json:"guest"`
+	Host            BillingDetailsResponse `json:"host"`
+	PropertyAddress string                 `json:"property_address"`
+	TimeFrom        time.Time              `json:"time_from"`
+	TimeTo          time.Time              `json:"time_to"`
+	Nights          int                    `json:"nights"`
+	Currency        string                 `json:"currency"`
+	Lines           []InvoiceLineResponse  `json:"lines"`
+	Subtotal        decimal.Decimal        `json:"subtotal"`
+	TaxTotal        decimal.Decimal        `json:"tax_total"`
+	Total           decimal.Decimal        `json:"total"`
+}
+
+// CalendarFeedResponse — секретная ссылка на iCal-ленту; кто её знает, видит занятые даты
+type CalendarFeedResponse struct {
+	ApartmentID string    `json:"apartment_id"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CalendarImportResponse struct {
+	Id           string     `json:"id"`
+	ApartmentID  string     `json:"apartment_id"`
+	URL          string     `json:"url"`
+	Name         string     `json:"name"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `json:"last_error,omitempty"`
+	EventCount   int        `json:"event_count"`
+}
+
+// CalendarConflictResponse — импортированное событие и бронь, на которую оно легло
+type CalendarConflictResponse struct {
+	Id         string          `json:"id"`
+	ImportID   string          `json:"import_id"`
+	Summary    string          `json:"summary"`
+	TimeFrom   time.Time       `json:"time_from"`
+	TimeTo     time.Time       `json:"time_to"`
+	Booking    BookingResponse `json:"booking"`
+	DetectedAt time.Time       `json:"detected_at"`
+}
+
+// ApartmentExportRow — апартамент в выгрузке портфеля; пакетный импорт принимает тот же формат
+type ApartmentExportRow struct {
+	Id          string            `json:"id"`
+	Address     string            `json:"address"`
+	Location    *AddressDTO       `json:"location,omitempty"`
+	Status      string            `json:"status"`
+	Price       decimal.Decimal   `json:"price"`
+	Currency    string            `json:"currency"`
+	TimeZone    string            `json:"time_zone"`
+	Language    string            `json:"language,omitempty"`
+	CleaningFee decimal.Decimal   `json:"cleaning_fee"`
+	Info        map[string]string `json:"info"`
+	Amenities   map[string]any    `json:"amenities"`
+}
+
+// ImportRowResponse — итог строки импорта: created, valid (пробный прогон),
+// skipped (строка верна, но атомарный импорт отменён) или failed
+type ImportRowResponse struct {
+	Line        int                       `json:"line"`
+	Status      string                    `json:"status"`
+	ApartmentID string                    `json:"apartment_id,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+	Fields      []servererrors.FieldError `json:"fields,omitempty"`
+}
+
+type ImportReportResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Mode    string              `json:"mode"`
+	Total   int                 `json:"total"`
+	Valid   int                 `json:"valid"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Rows    []ImportRowResponse `json:"rows"`
 }
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"booking_service/internal/config"
+	"fmt"
+	"time"
+)
+
+// NewFromConfig выбирает реализацию Publisher по BOOKING_EVENTS_PUBLISHER
+func NewFromConfig(cfg *config.Config) (Publisher, error) {
+	switch cfg.EVENTS_PUBLISHER {
+	case "log":
+		return NewLogPublisher(), nil
+	case "webhook":
+		if cfg.EVENTS_WEBHOOK_URL == "" {
+			return nil, fmt.Errorf("BOOKING_EVENTS_WEBHOOK_URL is required for webhook publisher")
+		}
+		return NewWebhookPublisher(cfg.EVENTS_WEBHOOK_URL, 10*time.Second), nil
+	case "nats":
+		return NewNATSPublisher(cfg.NATS_URL, cfg.NATS_SUBJECT_PREFIX)
+	default:
+		return nil, fmt.Errorf("unknown events publisher '%s'", cfg.EVENTS_PUBLISHER)
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Типы доменных событий
+const (
+	ApartmentCreated       = "apartment.created"
+	ApartmentUpdated       = "apartment.updated"
+	ApartmentStatusChanged = "apartment.status_changed"
+	ApartmentOwnerChanged  = "apartment.owner_changed"
+	BookingCreated         = "booking.created"
+	BookingConfirmed       = "booking.confirmed"
+	BookingPaymentFailed   = "booking.payment_failed"
+	BookingCancelled       = "booking.cancelled"
+	CalendarConflict       = "calendar.conflict"
+)
+
+// Types — все типы событий; подписки вебхуков могут выбирать из них
+var Types = []string{ApartmentCreated, ApartmentUpdated, ApartmentStatusChanged, ApartmentOwnerChanged,
+	BookingCreated, BookingConfirmed, BookingPaymentFailed, BookingCancelled, CalendarConflict}
+
+// Event — то, что уходит подписчикам; Payload — JSON из outbox как есть.
+// Seq — номер публикации (OutboxEvent.PublishSeq), растёт в порядке фиксации
+type Event struct {
+	ID          string          `json:"id"`
+	Seq         int64           `json:"seq"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Publisher доставляет одно событие. Ошибка означает, что событие будет отправлено повторно,
+// поэтому получатели должны быть идемпотентны по Event.ID
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// OwnerIDs — владельцы, которым адресовано событие (при передаче — оба)
+func OwnerIDs(payload json.RawMessage) []string {
+	var p struct {
+		OwnerID     string `json:"owner_id"`
+		FromOwnerID string `json:"from_owner_id"`
+		ToOwnerID   string `json:"to_owner_id"`
+	}
+	_ = json.Unmarshal(payload, &p)
+
+	ids := []string{}
+	for _, id := range []string{p.OwnerID, p.FromOwnerID, p.ToOwnerID} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type ApartmentPayload struct {
+	ID       string          `json:"id"`
+	OwnerID  string          `json:"owner_id"`
+	Status   string          `json:"status"`
+	Price    decimal.Decimal `json:"price"`
+	Currency string          `json:"currency"`
+	City     string          `json:"city"`
+}
+
+type ApartmentOwnerPayload struct {
+	ID          string `json:"id"`
+	FromOwnerID string `json:"from_owner_id"`
+	ToOwnerID   string `json:"to_owner_id"`
+	TransferID  string `json:"transfer_id"`
+}
+
+type BookingPayload struct {
+	ID          string    `json:"id"`
+	ApartmentID string    `json:"apartment_id"`
+	OwnerID     string    `json:"owner_id"`
+	UserID      string    `json:"user_id"`
+	TimeFrom    time.Time `json:"time_from"`
+	TimeTo      time.Time `json:"time_to"`
+	Guests      int       `json:"guests"`
+	CancelledBy string    `json:"cancelled_by,omitempty"`
+}
+
+// CalendarConflictPayload — событие внешнего календаря легло на уже принятую бронь
+type CalendarConflictPayload struct {
+	ID          string    `json:"id"`
+	ApartmentID string    `json:"apartment_id"`
+	OwnerID     string    `json:"owner_id"`
+	BookingID   string    `json:"booking_id"`
+	ImportID    string    `json:"import_id"`
+	Summary     string    `json:"summary"`
+	TimeFrom    time.Time `json:"time_from"`
+	TimeTo      time.Time `json:"time_to"`
+}
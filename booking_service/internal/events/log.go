@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogPublisher пишет события в лог; режим по умолчанию, когда брокера нет
+type LogPublisher struct{}
+
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, e Event) error {
+	logrus.WithFields(logrus.Fields{
+		"event_id":     e.ID,
+		"event_type":   e.Type,
+		"aggregate_id": e.AggregateID,
+		"payload":      string(e.Payload),
+	}).Info("Domain event")
+	return nil
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryPublisher хранит события в памяти; для тестов и локальной отладки.
+// Fail заставляет Publish возвращать ошибку, чтобы проверить повторную доставку
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+	fail   error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail != nil {
+		return p.fail
+	}
+	p.events = append(p.events, e)
+	return nil
+}
+
+// Fail задаёт ошибку для всех следующих Publish; nil возвращает нормальную работу
+func (p *MemoryPublisher) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fail = err
+}
+
+// Events возвращает копию принятых событий в порядке публикации
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]Event, len(p.events))
+	copy(out, p.events)
+	return out
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Sink — получатель событий релея. По Name релей помнит в outbox_deliveries, кому событие
+// уже доставлено, поэтому имя не должно меняться между запусками
+type Sink struct {
+	Name string
+	Publisher
+}
+
+// Multi — все получатели релея. Каждый отслеживается отдельно: ошибка одного
+// не приводит к повторной отправке остальным
+type Multi []Sink
+
+// deliver отправляет событие получателям, которых нет в done. Получатели из down упали
+// раньше в этой пачке и пропускаются; упавшие сейчас добавляются в down.
+// Возвращает имена принявших событие и ошибки остальных
+func (m Multi) deliver(ctx context.Context, e Event, done, down map[string]bool) ([]string, error) {
+	var accepted []string
+	var errs []error
+
+	for _, s := range m {
+		if done[s.Name] {
+			continue
+		}
+		if down[s.Name] {
+			errs = append(errs, fmt.Errorf("%s: unavailable", s.Name))
+			continue
+		}
+		if err := s.Publish(ctx, e); err != nil {
+			down[s.Name] = true
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+			continue
+		}
+		accepted = append(accepted, s.Name)
+	}
+
+	return accepted, errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeliverRetriesOnlyFailedSink(t *testing.T) {
+	bus, hooks := NewMemoryPublisher(), NewMemoryPublisher()
+	sinks := Multi{{Name: "bus", Publisher: bus}, {Name: "webhooks", Publisher: hooks}}
+	e := Event{ID: "e1", Type: BookingCreated}
+	done := map[string]bool{}
+
+	hooks.Fail(errors.New("connection refused"))
+	accepted, err := sinks.deliver(context.Background(), e, done, map[string]bool{})
+	if err == nil {
+		t.Fatal("expected error from failing sink")
+	}
+	if len(accepted) != 1 || accepted[0] != "bus" {
+		t.Fatalf("accepted = %v, want [bus]", accepted)
+	}
+	for _, name := range accepted {
+		done[name] = true
+	}
+
+	// повтор: событие уходит только упавшему получателю
+	hooks.Fail(nil)
+	accepted, err = sinks.deliver(context.Background(), e, done, map[string]bool{})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(accepted) != 1 || accepted[0] != "webhooks" {
+		t.Fatalf("accepted = %v, want [webhooks]", accepted)
+	}
+
+	if got := len(bus.Events()); got != 1 {
+		t.Errorf("bus got %d events, want exactly 1", got)
+	}
+	if got := len(hooks.Events()); got != 1 {
+		t.Errorf("webhooks got %d events, want 1", got)
+	}
+}
+
+func TestDeliverSkipsSinkDownInBatch(t *testing.T) {
+	bus, hooks := NewMemoryPublisher(), NewMemoryPublisher()
+	sinks := Multi{{Name: "bus", Publisher: bus}, {Name: "webhooks", Publisher: hooks}}
+	down := map[string]bool{}
+
+	hooks.Fail(errors.New("timeout"))
+	for _, id := range []string{"e1", "e2", "e3"} {
+		if _, err := sinks.deliver(context.Background(), Event{ID: id}, map[string]bool{}, down); err == nil {
+			t.Fatalf("%s: expected error", id)
+		}
+	}
+	hooks.Fail(nil)
+
+	// после первой ошибки упавший получатель до конца пачки не вызывается
+	if got := len(hooks.Events()); got != 0 {
+		t.Errorf("webhooks got %d events, want 0", got)
+	}
+	if got := len(bus.Events()); got != 3 {
+		t.Errorf("bus got %d events, want 3", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{9, 256 * time.Second},
+		{10, maxBackoff},
+		{100, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+// NATSPublisher публикует событие в subject <prefix>.<type>, например booking.apartment.created
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSPublisher(url, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("booking_service"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.prefix + "." + e.Type)
+	msg.Data = body
+	msg.Header.Set(nats.MsgIdHdr, e.ID)
+
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	// без Flush ошибка соединения обнаружится только на следующем событии
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Subscribe передаёт sink события, опубликованные релеем любого экземпляра сервиса.
+// Подписка без очереди: каждый экземпляр получает все события, как и нужно потокам SSE
+func (p *NATSPublisher) Subscribe(sink Publisher) (*nats.Subscription, error) {
+	return p.conn.Subscribe(p.prefix+".>", func(msg *nats.Msg) {
+		var e Event
+		if err := json.Unmarshal(msg.Data, &e); err != nil {
+			logrus.WithError(err).Warnf("Malformed event on %s", msg.Subject)
+			return
+		}
+		_ = sink.Publish(context.Background(), e)
+	})
+}
+
+func (p *NATSPublisher) Close() {
+	p.conn.Close()
+}
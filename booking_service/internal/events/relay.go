@@ -0,0 +1,212 @@
+package events
+
+import (
+	"booking_service/internal/models"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultBatchSize = 100
+	maxBackoff       = 5 * time.Minute
+	// claimLease — на сколько пачка закрепляется за релеем. Если релей не успел или упал,
+	// по истечении срока события снова попадут в выборку и уйдут повторно
+	claimLease = 5 * time.Minute
+)
+
+// Relay переносит события из outbox_events получателям. Пачка забирается короткой
+// транзакцией (SKIP LOCKED + сдвиг next_attempt_at на claimLease), публикация идёт
+// уже без блокировок, а результат по каждому получателю записывается сразу после неё.
+// PublishedAt ставится, когда событие приняли все: при падении между публикацией
+// и записью событие уйдёт ещё раз (at-least-once)
+type Relay struct {
+	db        *gorm.DB
+	sinks     Multi
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(db *gorm.DB, sinks Multi, interval time.Duration) *Relay {
+	return &Relay{db: db, sinks: sinks, interval: interval, batchSize: defaultBatchSize}
+}
+
+// Run опрашивает outbox, пока не отменён ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := r.relayBatch(ctx)
+			if err != nil {
+				logrus.WithError(err).Warn("Outbox relay failed")
+				break
+			}
+			// полная пачка — возможно, в очереди есть ещё
+			if n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim забирает пачку готовых к отправке событий и закрепляет её за собой на claimLease
+func (r *Relay) claim(ctx context.Context) ([]models.OutboxEvent, error) {
+	// TRANSACTION [BEGIN]
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	// номера публикации выдаются строго по одному релею за раз: тогда порядок номеров
+	// совпадает с порядком фиксации, и поток SSE не пропускает события по Last-Event-ID
+	if err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, models.OutboxPublishLock).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var batch []models.OutboxEvent
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("published_at IS NULL AND next_attempt_at <= ?", time.Now()).
+		Order("seq").
+		Limit(r.batchSize).
+		Find(&batch).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(batch) == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	ids := make([]string, len(batch))
+	for i := range batch {
+		ids[i] = batch[i].ID
+	}
+	if err := tx.Model(&models.OutboxEvent{}).
+		Where("event_id IN ?", ids).
+		Update("next_attempt_at", time.Now().Add(claimLease)).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// повторные попытки сохраняют номер, выданный в первый раз
+	if err := tx.Exec(`UPDATE outbox_events o SET publish_seq = n.publish_seq
+		FROM (SELECT event_id, nextval('outbox_publish_seq') AS publish_seq
+			FROM (SELECT event_id FROM outbox_events
+				WHERE event_id IN ? AND publish_seq IS NULL ORDER BY seq) s) n
+		WHERE o.event_id = n.event_id`, ids).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Where("event_id IN ?", ids).Order("publish_seq").Find(&batch).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	return batch, tx.Commit().Error
+}
+
+// delivered — кому из получателей события пачки уже доставлены при прошлых попытках
+func (r *Relay) delivered(ctx context.Context, batch []models.OutboxEvent) (map[string]map[string]bool, error) {
+	ids := make([]string, len(batch))
+	for i := range batch {
+		ids[i] = batch[i].ID
+	}
+
+	var rows []models.OutboxDelivery
+	if err := r.db.WithContext(ctx).Where("event_id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	done := map[string]map[string]bool{}
+	for _, d := range rows {
+		if done[d.EventID] == nil {
+			done[d.EventID] = map[string]bool{}
+		}
+		done[d.EventID][d.Sink] = true
+	}
+	return done, nil
+}
+
+// relayBatch публикует одну пачку и возвращает число событий, принятых всеми получателями.
+// Получатель, упавший на событии, до конца пачки не вызывается, чтобы не нарушать
+// порядок событий у него; остальные получают пачку целиком
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	batch, err := r.claim(ctx)
+	if err != nil || len(batch) == 0 {
+		return 0, err
+	}
+
+	done, err := r.delivered(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+
+	db := r.db.WithContext(ctx)
+	down := map[string]bool{}
+	published := 0
+	for i := range batch {
+		ev := &batch[i]
+		accepted, perr := r.sinks.deliver(ctx, Event{
+			ID:          ev.ID,
+			Seq:         *ev.PublishSeq,
+			Type:        ev.Type,
+			AggregateID: ev.AggregateID,
+			OccurredAt:  ev.CreatedAt,
+			Payload:     json.RawMessage(ev.Payload),
+		}, done[ev.ID], down)
+
+		for _, sink := range accepted {
+			if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.OutboxDelivery{EventID: ev.ID, Sink: sink}).Error; err != nil {
+				return published, err
+			}
+		}
+
+		if perr != nil {
+			ev.Attempts++
+			updates := map[string]any{
+				"attempts":        ev.Attempts,
+				"last_error":      perr.Error(),
+				"next_attempt_at": time.Now().Add(backoff(ev.Attempts)),
+			}
+			if err := db.Model(ev).Updates(updates).Error; err != nil {
+				return published, err
+			}
+			logrus.WithError(perr).Warnf("Event %s (%s) not published, attempt %d", ev.ID, ev.Type, ev.Attempts)
+			continue
+		}
+
+		if err := db.Model(ev).Update("published_at", time.Now()).Error; err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// backoff: 1s, 2s, 4s ... но не больше maxBackoff
+func backoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
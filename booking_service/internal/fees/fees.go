@@ -0,0 +1,128 @@
+package fees
+
+import (
+	"booking_service/internal/models"
+	"booking_service/internal/money"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// CityTax — туристический налог города: фиксированная сумма за ночь с гостя.
+// MaxNights > 0 ограничивает число облагаемых ночей
+type CityTax struct {
+	City             string          `json:"city"`
+	PerNightPerGuest decimal.Decimal `json:"per_night_per_guest"`
+	Currency         string          `json:"currency"`
+	MaxNights        int             `json:"max_nights"`
+}
+
+// Rules — сборы и налоги. ServiceFeeRate — доля от проживания и уборки (0.12 = 12%)
+type Rules struct {
+	ServiceFeeRate decimal.Decimal `json:"service_fee_rate"`
+	CityTaxes      []CityTax       `json:"city_taxes"`
+}
+
+//go:embed fees.json
+var defaultRules []byte
+
+// Load читает правила из JSON-файла; без пути используются правила, вшитые в сборку
+func Load(path string) (*Rules, error) {
+	raw := defaultRules
+	if path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var r Rules
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, fmt.Errorf("invalid fees file: %w", err)
+	}
+	if r.ServiceFeeRate.IsNegative() || r.ServiceFeeRate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, fmt.Errorf("invalid fees file: service_fee_rate must be in [0, 1)")
+	}
+	for i, t := range r.CityTaxes {
+		r.CityTaxes[i].Currency = money.Normalize(t.Currency)
+		if strings.TrimSpace(t.City) == "" || !money.IsSupported(r.CityTaxes[i].Currency) || t.PerNightPerGuest.IsNegative() {
+			return nil, fmt.Errorf("invalid fees file: bad city tax rule #%d", i+1)
+		}
+	}
+	return &r, nil
+}
+
+// Schedule считает строки стоимости проживания. Налог города пересчитывается
+// в валюту объявления по текущему курсу
+type Schedule struct {
+	rules *Rules
+	rates *money.Converter
+	taxes map[string]CityTax
+}
+
+func NewSchedule(rules *Rules, rates *money.Converter) *Schedule {
+	taxes := make(map[string]CityTax, len(rules.CityTaxes))
+	for _, t := range rules.CityTaxes {
+		taxes[cityKey(t.City)] = t
+	}
+	return &Schedule{rules: rules, rates: rates, taxes: taxes}
+}
+
+func cityKey(city string) string {
+	return strings.ToLower(strings.TrimSpace(city))
+}
+
+// Lines — строки стоимости: проживание, уборка, сервисный сбор и налог города.
+// Суммы округлены до валюты объявления, итог — сумма строк
+func (s *Schedule) Lines(ctx context.Context, ap *models.Apartment, nights, guests int) ([]models.BookingLineItem, error) {
+	currency := ap.Currency
+	line := func(kind, description string, quantity int, unit decimal.Decimal) models.BookingLineItem {
+		unit = money.Round(unit, currency)
+		return models.BookingLineItem{
+			Kind:        kind,
+			Description: description,
+			Quantity:    quantity,
+			UnitAmount:  unit,
+			Amount:      unit.Mul(decimal.NewFromInt(int64(quantity))),
+			Currency:    currency,
+		}
+	}
+
+	lines := []models.BookingLineItem{
+		line(models.LineAccommodation, fmt.Sprintf("Accommodation, %d nights", nights), nights, ap.Price),
+	}
+	if ap.CleaningFee.IsPositive() {
+		lines = append(lines, line(models.LineCleaningFee, "Cleaning fee", 1, ap.CleaningFee))
+	}
+
+	base := decimal.Zero
+	for _, l := range lines {
+		base = base.Add(l.Amount)
+	}
+	if fee := money.Round(base.Mul(s.rules.ServiceFeeRate), currency); fee.IsPositive() {
+		lines = append(lines, line(models.LineServiceFee, "Service fee", 1, fee))
+	}
+
+	if tax, ok := s.taxes[cityKey(ap.City)]; ok && tax.PerNightPerGuest.IsPositive() {
+		unit, err := s.rates.Convert(ctx, tax.PerNightPerGuest, tax.Currency, currency)
+		if err != nil {
+			return nil, err
+		}
+		taxed := nights
+		if tax.MaxNights > 0 && taxed > tax.MaxNights {
+			taxed = tax.MaxNights
+		}
+		lines = append(lines, line(models.LineCityTax,
+			fmt.Sprintf("City tax (%s), %d guests x %d nights", tax.City, guests, taxed), guests*taxed, unit))
+	}
+
+	for i := range lines {
+		lines[i].Position = i + 1
+	}
+	return lines, nil
+}
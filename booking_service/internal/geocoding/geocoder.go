@@ -0,0 +1,57 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrAddressNotFound = errors.New("address could not be geocoded")
+
+type Address struct {
+	Country    string
+	City       string
+	Street     string
+	PostalCode string
+}
+
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Geocoder переводит структурированный адрес в координаты
+type Geocoder interface {
+	Geocode(ctx context.Context, addr Address) (Point, error)
+}
+
+// ParseAddress разбирает строку вида "City, Street[, PostalCode][, Country]".
+// Город — первая часть, как и в поиске по городу.
+func ParseAddress(raw string) Address {
+	parts := strings.Split(raw, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	addr := Address{City: parts[0]}
+	if len(parts) > 1 {
+		addr.Street = parts[1]
+	}
+	if len(parts) > 2 {
+		addr.PostalCode = parts[2]
+	}
+	if len(parts) > 3 {
+		addr.Country = strings.Join(parts[3:], ", ")
+	}
+
+	return addr
+}
+
+// String собирает адрес обратно в формат ParseAddress, пропуская пустые части в конце
+func (a Address) String() string {
+	parts := []string{a.City, a.Street, a.PostalCode, a.Country}
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,39 @@
+package geocoding
+
+import "strings"
+
+// cityZones — часовые пояса городов, известных офлайн-геокодеру
+var cityZones = map[string]string{
+	"amsterdam":        "Europe/Amsterdam",
+	"barcelona":        "Europe/Madrid",
+	"berlin":           "Europe/Berlin",
+	"budapest":         "Europe/Budapest",
+	"kazan":            "Europe/Moscow",
+	"lisbon":           "Europe/Lisbon",
+	"london":           "Europe/London",
+	"moscow":           "Europe/Moscow",
+	"paris":            "Europe/Paris",
+	"prague":           "Europe/Prague",
+	"rome":             "Europe/Rome",
+	"saint petersburg": "Europe/Moscow",
+	"sochi":            "Europe/Moscow",
+	"vienna":           "Europe/Vienna",
+	"москва":           "Europe/Moscow",
+	"санкт-петербург":  "Europe/Moscow",
+	"казань":           "Europe/Moscow",
+	"сочи":             "Europe/Moscow",
+}
+
+// CityTimeZone — IANA-пояс города или пустая строка, если город неизвестен
+func CityTimeZone(city string) string {
+	return cityZones[strings.ToLower(strings.TrimSpace(city))]
+}
+
+// CityZones — копия таблицы поясов для миграции существующих апартаментов
+func CityZones() map[string]string {
+	out := make(map[string]string, len(cityZones))
+	for k, v := range cityZones {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package invoices
+
+import (
+	"booking_service/internal/models"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/shopspring/decimal"
+)
+
+// RenderPDF печатает счёт на одной странице A4. Встроенные шрифты PDF знают только
+// латиницу (cp1252), символы вне неё заменяются при печати
+func RenderPDF(inv *models.Invoice) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Invoice "+inv.Number, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Invoice "+tr(inv.Number), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, "Issued: "+inv.IssuedAt.Format(time.DateOnly), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Booking: "+inv.BookingID, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// реквизиты сторон в две колонки
+	top := pdf.GetY()
+	party(pdf, tr, 20, top, "Host", inv.HostID, inv.Host)
+	party(pdf, tr, 110, top, "Guest", inv.GuestID, inv.Guest)
+	pdf.SetXY(20, top+36)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(0, 5, tr(fmt.Sprintf("Stay: %s, %s - %s (%d nights)",
+		inv.PropertyAddress, inv.TimeFrom.In(inv.Location()).Format(time.DateOnly), inv.TimeTo.In(inv.Location()).Format(time.DateOnly), inv.Nights)), "", "L", false)
+	pdf.Ln(4)
+
+	widths := []float64{80, 15, 25, 20, 30}
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, h := range []string{"Description", "Qty", "Unit price", "Tax", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, h, "B", 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range inv.Lines {
+		pdf.CellFormat(widths[0], 7, tr(truncate(line.Description, 48)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 7, fmt.Sprint(line.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 7, line.UnitPrice.StringFixed(2), "", 0, "R", false, 0, "")
+		tax := percent(line.TaxRate)
+		if line.Kind == models.LineCityTax {
+			tax = "-"
+		}
+		pdf.CellFormat(widths[3], 7, tax, "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 7, line.Amount.StringFixed(2), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	totals := []struct {
+		label string
+		value decimal.Decimal
+	}{
+		{"Subtotal", inv.Subtotal},
+		{"Tax", inv.TaxTotal},
+		{"Total " + inv.Currency, inv.Total},
+	}
+	for i, t := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont("Helvetica", "B", 11)
+		}
+		pdf.CellFormat(140, 7, t.label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 7, t.value.StringFixed(2), "", 1, "R", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func party(pdf *gofpdf.Fpdf, tr func(string) string, x, y float64, title, userID string, d models.BillingDetails) {
+	pdf.SetXY(x, y)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(80, 6, title, "", 2, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	lines := []string{d.Name, d.Company, d.Address}
+	if d.TaxID != "" {
+		lines = append(lines, "Tax ID: "+d.TaxID)
+	}
+	if d.Name == "" && d.Company == "" {
+		lines = append(lines, "ID: "+userID)
+	}
+	for _, l := range lines {
+		if l != "" {
+			pdf.CellFormat(80, 5, tr(truncate(l, 60)), "", 2, "L", false, 0, "")
+		}
+	}
+}
+
+func percent(rate decimal.Decimal) string {
+	return rate.Shift(2).StringFixed(0) + "%"
+}
+
+func truncate(s string, max int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= max {
+		return string(r)
+	}
+	return string(r[:max-1]) + "…"
+}
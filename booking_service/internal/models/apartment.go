@@ -2,21 +2,59 @@ package models
 
 import (
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Статусы объявления: draft — черновик, виден только владельцу; published — в поиске;
+// unlisted — доступен по прямой ссылке; archived — мягко удалён, бронирования сохраняются
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusUnlisted  = "unlisted"
+	StatusArchived  = "archived"
 )
 
 type Apartment struct {
 	ID           string    `gorm:"column:id;type:uuid;primaryKey"`
 	OwnerID      string    `gorm:"column:owner_id;type:uuid;not null"`
 	Address      string    `gorm:"column:address;uniqueIndex;not null"`
-	Price        float64   `gorm:"column:price;type:decimal(10,2)"`
-	UpdatedAt    time.Time `gorm:"column:updated_at;type:timestamp without time zone;default:now();not null;"`
+	Country      string    `gorm:"column:country"`
+	City         string    `gorm:"column:city;index"`
+	Street       string    `gorm:"column:street"`
+	PostalCode   string    `gorm:"column:postal_code"`
+	TimeZone     string    `gorm:"column:time_zone;default:'UTC';not null"`
+	Lat          *float64  `gorm:"column:lat;type:double precision;index:idx_apartments_geo,priority:1"`
+	Lng          *float64  `gorm:"column:lng;type:double precision;index:idx_apartments_geo,priority:2"`
+	Price        decimal.Decimal `gorm:"column:price;type:decimal(10,2)"`
+	Currency     string    `gorm:"column:currency;size:3;default:'EUR';not null"`
+	CleaningFee  decimal.Decimal `gorm:"column:cleaning_fee;type:decimal(10,2);default:0;not null"`
+	Status       string     `gorm:"column:status;default:'published';not null;index"`
+	ArchivedAt   *time.Time `gorm:"column:archived_at;type:timestamptz"`
+	SearchLang   string    `gorm:"column:search_lang;default:'english';not null"`
+	SearchVector string    `gorm:"column:search_vector;type:tsvector;index:idx_apartments_search,type:gin;->:false;<-:false"`
+	RatingAvg    *float64  `gorm:"column:rating_avg;type:numeric(3,2)"`
+	RatingCount  int       `gorm:"column:rating_count;default:0;not null"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;type:timestamptz;default:now();not null;"`
 
 	// Relations
 	Bookings     []Booking       `gorm:"foreignKey:ApartmentID;constraint:OnDelete:CASCADE"`
 	Descriptions []Description   `gorm:"foreignKey:ApartmentID;constraint:OnDelete:CASCADE"`
 	History      []ApartmentSCD4 `gorm:"foreignKey:ApartmentID;constraint:OnDelete:CASCADE"`
+	Photos       []ApartmentPhoto `gorm:"foreignKey:ApartmentID;constraint:OnDelete:CASCADE"`
+	CoHosts      []CoHost         `gorm:"foreignKey:ApartmentID;constraint:OnDelete:CASCADE"`
 }
 
 func (Apartment) TableName() string {
 	return "apartments"
 }
+
+// Location — часовой пояс апартамента: в нём считаются даты заезда и выезда
+func (a *Apartment) Location() *time.Location {
+	return LoadZone(a.TimeZone)
+}
+
+// IsVisible — можно ли показывать объявление по прямой ссылке
+func (a *Apartment) IsVisible() bool {
+	return a.Status == StatusPublished || a.Status == StatusUnlisted
+}
@@ -2,14 +2,44 @@ package models
 
 import "time"
 
+// Бронь ждёт авторизации платежа в статусе pending_payment и уже держит даты;
+// после авторизации становится confirmed, при отказе — payment_failed и даты освобождаются
+const (
+	BookingPendingPayment = "pending_payment"
+	BookingConfirmed      = "confirmed"
+	BookingPaymentFailed  = "payment_failed"
+	BookingCancelled      = "cancelled"
+)
+
+// Guests — состав гостей брони. Вместимость ограничивает взрослых и детей, младенцы не считаются
+type Guests struct {
+	Adults   int `gorm:"column:adults;default:1;not null"`
+	Children int `gorm:"column:children;default:0;not null"`
+	Infants  int `gorm:"column:infants;default:0;not null"`
+	Pets     int `gorm:"column:pets;default:0;not null"`
+}
+
+// Count — число гостей, занимающих места
+func (g Guests) Count() int {
+	return g.Adults + g.Children
+}
+
 type Booking struct {
-	ID          string    `gorm:"column:booking_id;primaryKey"`
-	UserID      string    `gorm:"column:user_id;type:uuid;not null"`
-	ApartmentID string    `gorm:"column:ap_id;type:uuid;index;not null"`
-	TimeFrom    time.Time `gorm:"column:time_from;type:timestamp without time zone;default:now()"`
-	TimeTo      time.Time `gorm:"column:time_to;type:timestamp without time zone;default:('9999-12-31 23:59:00'::timestamp)"`
+	ID          string     `gorm:"column:booking_id;primaryKey"`
+	UserID      string     `gorm:"column:user_id;type:uuid;not null"`
+	ApartmentID string     `gorm:"column:ap_id;type:uuid;index;not null"`
+	TimeFrom    time.Time  `gorm:"column:time_from;type:timestamptz;default:now()"`
+	TimeTo      time.Time  `gorm:"column:time_to;type:timestamptz;default:('9999-12-31 23:59:00+00'::timestamptz)"`
+	CheckIn     time.Time  `gorm:"column:check_in;type:date"` // местные даты апартамента
+	CheckOut    time.Time  `gorm:"column:check_out;type:date"`
+	Status      string     `gorm:"column:status;default:'confirmed';not null;index"`
+	Guests      Guests     `gorm:"embedded"`
+	CancelledAt *time.Time `gorm:"column:cancelled_at;type:timestamptz"`
+	CancelledBy *string    `gorm:"column:cancelled_by;type:uuid"`
 
-	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
+	Apartment Apartment         `gorm:"foreignKey:ApartmentID;references:ID"`
+	Payment   *Payment          `gorm:"foreignKey:BookingID;references:ID"`
+	LineItems []BookingLineItem `gorm:"foreignKey:BookingID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
 func (Booking) TableName() string {
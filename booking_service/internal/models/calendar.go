@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// CalendarFeed — секретная ссылка на iCal-ленту апартамента. Токен заменяется целиком,
+// старая ссылка после этого перестаёт работать
+type CalendarFeed struct {
+	ApartmentID string    `gorm:"column:ap_id;type:uuid;primaryKey"`
+	Token       string    `gorm:"column:token;uniqueIndex;not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
+
+// CalendarImport — внешний iCal-календарь (другая площадка), из которого периодически
+// забираются занятые периоды
+type CalendarImport struct {
+	ID           string     `gorm:"column:import_id;type:uuid;primaryKey"`
+	ApartmentID  string     `gorm:"column:ap_id;type:uuid;index;not null"`
+	URL          string     `gorm:"column:url;not null"`
+	Name         string     `gorm:"column:name"`
+	CreatedBy    string     `gorm:"column:created_by;type:uuid;not null"`
+	CreatedAt    time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	LastSyncedAt *time.Time `gorm:"column:last_synced_at;type:timestamptz;index"`
+	LastError    string     `gorm:"column:last_error"`
+	EventCount   int        `gorm:"column:event_count;default:0;not null"`
+
+	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
+}
+
+func (CalendarImport) TableName() string {
+	return "calendar_imports"
+}
+
+// CalendarBlock — занятый период из внешнего календаря; бронировать поверх него нельзя.
+// Блоки импорта заменяются целиком при каждой синхронизации
+type CalendarBlock struct {
+	ID          string    `gorm:"column:block_id;type:uuid;primaryKey"`
+	ImportID    string    `gorm:"column:import_id;type:uuid;not null;uniqueIndex:idx_calendar_blocks_uid"`
+	ApartmentID string    `gorm:"column:ap_id;type:uuid;not null;index:idx_calendar_blocks_period"`
+	UID         string    `gorm:"column:uid;not null;uniqueIndex:idx_calendar_blocks_uid"`
+	Summary     string    `gorm:"column:summary"`
+	TimeFrom    time.Time `gorm:"column:time_from;type:timestamptz;not null;index:idx_calendar_blocks_period"`
+	TimeTo      time.Time `gorm:"column:time_to;type:timestamptz;not null"`
+
+	Import CalendarImport `gorm:"foreignKey:ImportID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (CalendarBlock) TableName() string {
+	return "calendar_blocks"
+}
+
+// CalendarConflict — импортированный период пересёкся с бронью, уже принятой у нас.
+// Удаляется вместе с блоком, когда событие пропадает из внешнего календаря
+type CalendarConflict struct {
+	ID          string    `gorm:"column:conflict_id;type:uuid;primaryKey"`
+	ApartmentID string    `gorm:"column:ap_id;type:uuid;index;not null"`
+	BlockID     string    `gorm:"column:block_id;type:uuid;not null;uniqueIndex:idx_calendar_conflicts_pair"`
+	BookingID   string    `gorm:"column:booking_id;not null;uniqueIndex:idx_calendar_conflicts_pair"`
+	DetectedAt  time.Time `gorm:"column:detected_at;type:timestamptz;default:now();not null"`
+
+	Block   CalendarBlock `gorm:"foreignKey:BlockID;references:ID;constraint:OnDelete:CASCADE"`
+	Booking Booking       `gorm:"foreignKey:BookingID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (CalendarConflict) TableName() string {
+	return "calendar_conflicts"
+}
+
+// CalendarEvent — событие внешнего календаря после разбора. У событий на весь день
+// AllDay = true, а Start и End — местные даты апартамента (полночь UTC)
+type CalendarEvent struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+}
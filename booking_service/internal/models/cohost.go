@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Права, которые владелец может выдать соавтору (co-host); PermOwner выдать нельзя
+const (
+	PermOwner          = "owner"
+	PermEditPricing    = "edit_pricing"
+	PermEditListing    = "edit_listing"
+	PermManageBookings = "manage_bookings"
+)
+
+var CoHostPermissions = []string{PermEditPricing, PermEditListing, PermManageBookings}
+
+type CoHost struct {
+	ApartmentID       string    `gorm:"column:ap_id;type:uuid;primaryKey"`
+	UserID            string    `gorm:"column:user_id;type:uuid;primaryKey;index"`
+	CanEditPricing    bool      `gorm:"column:can_edit_pricing;default:false;not null"`
+	CanEditListing    bool      `gorm:"column:can_edit_listing;default:false;not null"`
+	CanManageBookings bool      `gorm:"column:can_manage_bookings;default:false;not null"`
+	GrantedBy         string    `gorm:"column:granted_by;type:uuid;not null"`
+	CreatedAt         time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+
+	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
+}
+
+func (CoHost) TableName() string {
+	return "apartment_cohosts"
+}
+
+func (c *CoHost) Allows(permission string) bool {
+	switch permission {
+	case PermEditPricing:
+		return c.CanEditPricing
+	case PermEditListing:
+		return c.CanEditListing
+	case PermManageBookings:
+		return c.CanManageBookings
+	}
+	return false
+}
+
+func (c *CoHost) Permissions() []string {
+	out := []string{}
+	for _, p := range CoHostPermissions {
+		if c.Allows(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
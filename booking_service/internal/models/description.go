@@ -1,15 +1,22 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// OpenEnd — valid_to действующей версии описания
+var OpenEnd = time.Date(9999, 12, 31, 23, 59, 0, 0, time.UTC)
 
 type Description struct {
 	ID          string    `gorm:"column:desc_id;primaryKey"`
 	ApartmentID string    `gorm:"column:ap_id;type:uuid;index;not null"`
-	ValidFrom   time.Time `gorm:"column:valid_from;type:timestamp without time zone;default:now()"`
-	ValidTo     time.Time `gorm:"column:valid_to;type:timestamp without time zone;default:'9999-12-31 23:59:00'"`
+	ValidFrom   time.Time `gorm:"column:valid_from;type:timestamptz;default:now()"`
+	ValidTo     time.Time `gorm:"column:valid_to;type:timestamptz;default:'9999-12-31 23:59:00+00'"`
 	Rooms       int       `gorm:"column:rooms;default:-1"`
 	Beds        int       `gorm:"column:beds;default:-1"`
 	Description string    `gorm:"column:desc;type:text"`
+	Amenities   string    `gorm:"column:amenities;type:jsonb;default:'{}';not null;index:idx_description_amenities,type:gin"`
 
 	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
 }
@@ -17,3 +24,33 @@ type Description struct {
 func (Description) TableName() string {
 	return "description"
 }
+
+// AmenityMap читает сохранённые удобства; битый JSON считается пустым набором
+func (d *Description) AmenityMap() map[string]any {
+	out := map[string]any{}
+	if d.Amenities != "" {
+		_ = json.Unmarshal([]byte(d.Amenities), &out)
+	}
+	return out
+}
+
+// MaxGuests — вместимость: удобство max_guests, иначе по две персоны на кровать.
+// 0 — вместимость неизвестна и не ограничивается
+func (d *Description) MaxGuests() int {
+	if v, ok := d.AmenityMap()["max_guests"].(float64); ok && v > 0 {
+		return int(v)
+	}
+	if d.Beds > 0 {
+		return d.Beds * 2
+	}
+	return 0
+}
+
+func (d *Description) PetsAllowed() bool {
+	allowed, _ := d.AmenityMap()["pets_allowed"].(bool)
+	return allowed
+}
+
+func (d *Description) IsCurrent() bool {
+	return d.ValidTo.Equal(OpenEnd)
+}
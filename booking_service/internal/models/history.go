@@ -2,14 +2,16 @@ package models
 
 import (
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type ApartmentSCD4 struct {
-	ID           string    `gorm:"column:id;type:uuid;primaryKey"`
-	ApartmentID  string    `gorm:"column:ap_id;index;type:uuid;not null"`
-	Price        float64   `gorm:"column:price;type:decimal(10,2)"`
-	UpdatedAt    time.Time `gorm:"type:timestamptz;default:now();not null;"`
-	InvalidSince time.Time `gorm:"type:timestamptz;default:now();not null;"`
+	ID           string          `gorm:"column:id;type:uuid;primaryKey"`
+	ApartmentID  string          `gorm:"column:ap_id;index;type:uuid;not null"`
+	Price        decimal.Decimal `gorm:"column:price;type:decimal(10,2)"`
+	UpdatedAt    time.Time       `gorm:"type:timestamptz;default:now();not null;"`
+	InvalidSince time.Time       `gorm:"type:timestamptz;default:now();not null;"`
 
 	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
 }
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BillingDetails — реквизиты для счёта; в счёт попадает их копия на момент выставления
+type BillingDetails struct {
+	Name    string `gorm:"column:name"`
+	Company string `gorm:"column:company"`
+	TaxID   string `gorm:"column:tax_id"`
+	Address string `gorm:"column:address"`
+}
+
+// BillingProfile — реквизиты пользователя (гостя или хоста)
+type BillingProfile struct {
+	UserID         string         `gorm:"column:user_id;type:uuid;primaryKey"`
+	BillingDetails BillingDetails `gorm:"embedded"`
+	UpdatedAt      time.Time      `gorm:"column:updated_at;type:timestamptz;default:now();not null"`
+}
+
+func (BillingProfile) TableName() string {
+	return "billing_profiles"
+}
+
+// InvoiceCounter — последний выданный номер в серии (по году)
+type InvoiceCounter struct {
+	Series string `gorm:"column:series;primaryKey"`
+	Last   int64  `gorm:"column:last;not null;default:0"`
+}
+
+func (InvoiceCounter) TableName() string {
+	return "invoice_counters"
+}
+
+// Invoice — счёт по оплаченной брони. Выставляется один раз и дальше не меняется
+type Invoice struct {
+	ID              string          `gorm:"column:invoice_id;type:uuid;primaryKey"`
+	Number          string          `gorm:"column:number;not null;uniqueIndex"`
+	Series          string          `gorm:"column:series;not null"`
+	Seq             int64           `gorm:"column:seq;not null"`
+	BookingID       string          `gorm:"column:booking_id;not null;uniqueIndex"`
+	PaymentID       string          `gorm:"column:payment_id;type:uuid;not null"`
+	IssuedAt        time.Time       `gorm:"column:issued_at;type:timestamptz;not null"`
+	Currency        string          `gorm:"column:currency;not null"`
+	Subtotal        decimal.Decimal `gorm:"column:subtotal;type:numeric(12,2);not null"`
+	TaxTotal        decimal.Decimal `gorm:"column:tax_total;type:numeric(12,2);not null"`
+	Total           decimal.Decimal `gorm:"column:total;type:numeric(12,2);not null"`
+	GuestID         string          `gorm:"column:guest_id;type:uuid;not null"`
+	Guest           BillingDetails  `gorm:"embedded;embeddedPrefix:guest_"`
+	HostID          string          `gorm:"column:host_id;type:uuid;not null"`
+	Host            BillingDetails  `gorm:"embedded;embeddedPrefix:host_"`
+	PropertyAddress string          `gorm:"column:property_address;not null"`
+	TimeFrom        time.Time       `gorm:"column:time_from;type:timestamptz;not null"`
+	TimeTo          time.Time       `gorm:"column:time_to;type:timestamptz;not null"`
+	TimeZone        string          `gorm:"column:time_zone;default:'UTC';not null"`
+	Nights          int             `gorm:"column:nights;not null"`
+
+	Lines []InvoiceLine `gorm:"foreignKey:InvoiceID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+func (i *Invoice) Location() *time.Location {
+	return LoadZone(i.TimeZone)
+}
+
+type InvoiceLine struct {
+	ID          int64           `gorm:"column:line_id;primaryKey;autoIncrement"`
+	InvoiceID   string          `gorm:"column:invoice_id;type:uuid;not null;index"`
+	Position    int             `gorm:"column:position;not null"`
+	Kind        string          `gorm:"column:kind;not null;default:'accommodation'"`
+	Description string          `gorm:"column:description;not null"`
+	Quantity    int             `gorm:"column:quantity;not null"`
+	UnitPrice   decimal.Decimal `gorm:"column:unit_price;type:numeric(12,2);not null"`
+	Amount      decimal.Decimal `gorm:"column:amount;type:numeric(12,2);not null"`
+	TaxRate     decimal.Decimal `gorm:"column:tax_rate;type:numeric(6,4);not null"` // 0.2 = 20%
+	TaxAmount   decimal.Decimal `gorm:"column:tax_amount;type:numeric(12,2);not null"`
+}
+
+func (InvoiceLine) TableName() string {
+	return "invoice_lines"
+}
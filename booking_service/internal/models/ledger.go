@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Виды проводок. Вместе с Reference вид однозначно определяет проводку, поэтому
+// повторное проведение той же операции ничего не меняет
+const (
+	LedgerCharge  = "charge"  // деньги гостя списаны: доля хоста + комиссия + сборы и налоги
+	LedgerRefund  = "refund"  // обратная к charge
+	LedgerRelease = "release" // после заезда доля хоста становится доступной к выплате
+	LedgerPayout  = "payout"  // выплата хосту
+)
+
+// Счета. Дебет — положительная сумма, кредит — отрицательная; сумма проводки всегда 0.
+// Счета хостов — обязательства платформы, поэтому их остаток — минус сумма записей
+const (
+	AccountPlatformCash = "platform_cash"
+	AccountCommission   = "platform_commission"
+	AccountServiceFees  = "platform_service_fees"
+	AccountTaxPayable   = "platform_tax_payable" // собранные налоги, которые платформа должна городам
+)
+
+func HostPendingAccount(ownerID string) string {
+	return "host_pending:" + ownerID
+}
+
+func HostAvailableAccount(ownerID string) string {
+	return "host_available:" + ownerID
+}
+
+type LedgerTransaction struct {
+	ID        string    `gorm:"column:transaction_id;type:uuid;primaryKey"`
+	Kind      string    `gorm:"column:kind;not null;uniqueIndex:idx_ledger_transactions_ref"`
+	Reference string    `gorm:"column:reference;not null;uniqueIndex:idx_ledger_transactions_ref"`
+	BookingID *string   `gorm:"column:booking_id;index"`
+	OwnerID   string    `gorm:"column:owner_id;type:uuid;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+
+	Entries []LedgerEntry `gorm:"foreignKey:TransactionID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (LedgerTransaction) TableName() string {
+	return "ledger_transactions"
+}
+
+type LedgerEntry struct {
+	ID            int64     `gorm:"column:entry_id;primaryKey;autoIncrement"`
+	TransactionID string    `gorm:"column:transaction_id;type:uuid;not null;index"`
+	Account       string    `gorm:"column:account;not null;index"`
+	OwnerID       *string   `gorm:"column:owner_id;type:uuid;index"` // для счетов хостов
+	Amount        int64     `gorm:"column:amount;not null"`
+	Currency      string    `gorm:"column:currency;not null"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+}
+
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+// Payout — выплата хосту накопленного доступного остатка в одной валюте
+type Payout struct {
+	ID        string    `gorm:"column:payout_id;type:uuid;primaryKey"`
+	OwnerID   string    `gorm:"column:owner_id;type:uuid;not null;index"`
+	Amount    int64     `gorm:"column:amount;not null"`
+	Currency  string    `gorm:"column:currency;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+}
+
+func (Payout) TableName() string {
+	return "payouts"
+}
+
+// OwnerBalance — остатки хоста в одной валюте, в минимальных единицах
+type OwnerBalance struct {
+	Currency  string
+	Pending   int64
+	Available int64
+	PaidOut   int64
+}
@@ -0,0 +1,34 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// Виды строк стоимости брони
+const (
+	LineAccommodation = "accommodation"
+	LineCleaningFee   = "cleaning_fee"
+	LineServiceFee    = "service_fee" // сбор платформы с гостя
+	LineCityTax       = "city_tax"    // туристический налог, платформа перечисляет его городу
+)
+
+// BookingLineItem — строка стоимости брони. Фиксируется при бронировании, чтобы оплата и счёт
+// не зависели от последующих изменений цен и правил
+type BookingLineItem struct {
+	ID          int64           `gorm:"column:line_id;primaryKey;autoIncrement"`
+	BookingID   string          `gorm:"column:booking_id;not null;index"`
+	Position    int             `gorm:"column:position;not null"`
+	Kind        string          `gorm:"column:kind;not null"`
+	Description string          `gorm:"column:description;not null"`
+	Quantity    int             `gorm:"column:quantity;not null"`
+	UnitAmount  decimal.Decimal `gorm:"column:unit_amount;type:numeric(12,2);not null"`
+	Amount      decimal.Decimal `gorm:"column:amount;type:numeric(12,2);not null"`
+	Currency    string          `gorm:"column:currency;size:3;not null"`
+}
+
+func (BookingLineItem) TableName() string {
+	return "booking_line_items"
+}
+
+// IsTax — строка налога: в счёте она идёт в сумму налогов, а не в стоимость услуг
+func (l *BookingLineItem) IsTax() bool {
+	return l.Kind == LineCityTax
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Thread — переписка гостя с владельцем апартамента: по брони (BookingID задан)
+// или запрос до бронирования (BookingID пуст). Второй участник — текущий владелец апартамента
+type Thread struct {
+	ID            string    `gorm:"column:thread_id;type:uuid;primaryKey"`
+	ApartmentID   string    `gorm:"column:ap_id;type:uuid;not null;index"`
+	BookingID     *string   `gorm:"column:booking_id"`
+	GuestID       string    `gorm:"column:guest_id;type:uuid;not null;index"`
+	CreatedAt     time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	LastMessageAt time.Time `gorm:"column:last_message_at;type:timestamptz;default:now();not null"`
+
+	// Unread вычисляется запросом для конкретного пользователя
+	Unread int64 `gorm:"column:unread;->;-:migration"`
+
+	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
+}
+
+func (Thread) TableName() string {
+	return "message_threads"
+}
+
+type Message struct {
+	ID        string    `gorm:"column:message_id;type:uuid;primaryKey"`
+	ThreadID  string    `gorm:"column:thread_id;type:uuid;not null;index:idx_messages_thread_time,priority:1"`
+	SenderID  string    `gorm:"column:sender_id;type:uuid;not null"`
+	Body      string    `gorm:"column:body;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null;index:idx_messages_thread_time,priority:2"`
+
+	Attachments []MessageAttachment `gorm:"foreignKey:MessageID;references:ID;constraint:OnDelete:CASCADE"`
+	Thread      Thread              `gorm:"foreignKey:ThreadID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (Message) TableName() string {
+	return "messages"
+}
+
+type MessageAttachment struct {
+	ID          string `gorm:"column:attachment_id;type:uuid;primaryKey"`
+	MessageID   string `gorm:"column:message_id;type:uuid;not null;index"`
+	FileName    string `gorm:"column:file_name;not null"`
+	ContentType string `gorm:"column:content_type;not null"`
+	Size        int64  `gorm:"column:size;not null"`
+	Key         string `gorm:"column:storage_key;not null"`
+}
+
+func (MessageAttachment) TableName() string {
+	return "message_attachments"
+}
+
+// ThreadRead — до какого момента участник прочитал переписку (read receipt)
+type ThreadRead struct {
+	ThreadID   string    `gorm:"column:thread_id;type:uuid;primaryKey"`
+	UserID     string    `gorm:"column:user_id;type:uuid;primaryKey"`
+	LastReadAt time.Time `gorm:"column:last_read_at;type:timestamptz;not null"`
+}
+
+func (ThreadRead) TableName() string {
+	return "message_thread_reads"
+}
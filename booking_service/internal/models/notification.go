@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Notification — отправленное пользователю уведомление. Строка же служит защитой от
+// повторной отправки: (ref_id, user_id, template) уникальны. InApp — показывать ли в ленте
+type Notification struct {
+	ID        string     `gorm:"column:notification_id;type:uuid;primaryKey"`
+	UserID    string     `gorm:"column:user_id;type:uuid;not null;index;uniqueIndex:idx_notifications_ref"`
+	Template  string     `gorm:"column:template;not null;uniqueIndex:idx_notifications_ref"`
+	RefID     string     `gorm:"column:ref_id;not null;uniqueIndex:idx_notifications_ref"`
+	Subject   string     `gorm:"column:subject;not null"`
+	Body      string     `gorm:"column:body;not null"`
+	InApp     bool       `gorm:"column:in_app;not null"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	ReadAt    *time.Time `gorm:"column:read_at;type:timestamptz"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference — каналы и язык уведомлений пользователя; без строки действуют умолчания
+type NotificationPreference struct {
+	UserID    string    `gorm:"column:user_id;type:uuid;primaryKey"`
+	Locale    string    `gorm:"column:locale;not null"`
+	Email     bool      `gorm:"column:email;not null"`
+	Push      bool      `gorm:"column:push;not null"`
+	InApp     bool      `gorm:"column:in_app;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:timestamptz;default:now();not null"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+func DefaultNotificationPreference(userID string) NotificationPreference {
+	return NotificationPreference{UserID: userID, Locale: "en", Email: true, Push: true, InApp: true}
+}
+
+// Allows — включён ли внешний канал (email, push)
+func (p *NotificationPreference) Allows(channel string) bool {
+	switch channel {
+	case "email":
+		return p.Email
+	case "push":
+		return p.Push
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// OutboxPublishLock — ключ advisory-блокировки, под которой релей нумерует события
+const OutboxPublishLock = 0x6f7574626f78
+
+// OutboxEvent — доменное событие, записанное в той же транзакции, что и изменение;
+// релей публикует его и проставляет PublishedAt (доставка at-least-once).
+// Seq выдаётся при вставке, и транзакции фиксируются не в его порядке. PublishSeq релей
+// выдаёт под OutboxPublishLock, когда забирает событие: это порядок фиксации, по нему
+// нумеруются события в потоке SSE
+type OutboxEvent struct {
+	ID            string     `gorm:"column:event_id;type:uuid;primaryKey"`
+	Seq           int64      `gorm:"column:seq;type:bigint;autoIncrement;uniqueIndex"`
+	PublishSeq    *int64     `gorm:"column:publish_seq;type:bigint;uniqueIndex"`
+	Type          string     `gorm:"column:event_type;not null"`
+	AggregateID   string     `gorm:"column:aggregate_id;type:uuid;not null;index"`
+	Payload       string     `gorm:"column:payload;type:jsonb;not null"`
+	CreatedAt     time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	PublishedAt   *time.Time `gorm:"column:published_at;type:timestamptz"`
+	Attempts      int        `gorm:"column:attempts;default:0;not null"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;type:timestamptz;default:now();not null"`
+	LastError     string     `gorm:"column:last_error"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxDelivery — событие принято получателем Sink. При повторе релей отправляет
+// событие только тем, кому оно ещё не доставлено
+type OutboxDelivery struct {
+	EventID     string    `gorm:"column:event_id;type:uuid;primaryKey"`
+	Sink        string    `gorm:"column:sink;primaryKey"`
+	DeliveredAt time.Time `gorm:"column:delivered_at;type:timestamptz;default:now();not null"`
+
+	Event OutboxEvent `gorm:"foreignKey:EventID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (OutboxDelivery) TableName() string {
+	return "outbox_deliveries"
+}
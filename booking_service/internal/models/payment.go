@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Жизненный цикл платежа: pending -> authorized -> captured -> refunded.
+// failed и voided — конечные: авторизация отклонена или снята до списания
+const (
+	PaymentPending    = "pending"
+	PaymentAuthorized = "authorized"
+	PaymentCaptured   = "captured"
+	PaymentRefunded   = "refunded"
+	PaymentFailed     = "failed"
+	PaymentVoided     = "voided"
+)
+
+// Payment — оплата брони у внешнего провайдера. Суммы в минимальных единицах валюты (центах)
+type Payment struct {
+	ID             string    `gorm:"column:payment_id;type:uuid;primaryKey"`
+	BookingID      string    `gorm:"column:booking_id;not null;uniqueIndex"`
+	Provider       string    `gorm:"column:provider;not null"`
+	ProviderRef    string    `gorm:"column:provider_ref;not null;default:''"`
+	Amount         int64     `gorm:"column:amount;not null"`
+	Currency       string    `gorm:"column:currency;not null"`
+	CommissionBps  int       `gorm:"column:commission_bps;not null;default:0"` // комиссия платформы на момент брони, в сотых процента
+	Status         string    `gorm:"column:status;not null;default:'pending';index"`
+	RefundedAmount int64     `gorm:"column:refunded_amount;not null;default:0"`
+	FailureReason  string    `gorm:"column:failure_reason"`
+	CreatedAt      time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;type:timestamptz;default:now();not null"`
+
+	Booking Booking `gorm:"foreignKey:BookingID;references:ID"`
+}
+
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// PaymentWebhookEvent — уже обработанные события провайдера; повторная доставка пропускается
+type PaymentWebhookEvent struct {
+	Provider   string    `gorm:"column:provider;primaryKey"`
+	EventID    string    `gorm:"column:event_id;primaryKey"`
+	PaymentID  string    `gorm:"column:payment_id;type:uuid;not null"`
+	ReceivedAt time.Time `gorm:"column:received_at;type:timestamptz;default:now();not null"`
+}
+
+func (PaymentWebhookEvent) TableName() string {
+	return "payment_webhook_events"
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type ApartmentPhoto struct {
+	ID          string    `gorm:"column:photo_id;type:uuid;primaryKey"`
+	ApartmentID string    `gorm:"column:ap_id;type:uuid;index;not null"`
+	Position    int       `gorm:"column:position;not null"`
+	IsCover     bool      `gorm:"column:is_cover;default:false;not null"`
+	Extension   string    `gorm:"column:ext;not null"`
+	ContentType string    `gorm:"column:content_type;not null"`
+	Width       int       `gorm:"column:width;not null"`
+	Height      int       `gorm:"column:height;not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+
+	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
+}
+
+func (ApartmentPhoto) TableName() string {
+	return "apartment_photos"
+}
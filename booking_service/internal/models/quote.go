@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Quote — расчёт стоимости проживания в валюте объявления; Total — сумма строк
+type Quote struct {
+	ApartmentID   string
+	TimeFrom      time.Time
+	TimeTo        time.Time
+	TimeZone      string
+	Nights        int
+	Guests        Guests
+	Currency      string
+	PricePerNight decimal.Decimal
+	Lines         []BookingLineItem
+	Total         decimal.Decimal
+}
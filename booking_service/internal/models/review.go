@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ReviewOfApartment = "apartment" // гость об апартаменте
+	ReviewOfGuest     = "guest"     // хост о госте
+)
+
+// ReviewWindow — сколько после выезда принимаются отзывы; по его окончании
+// отзыв публикуется, даже если вторая сторона промолчала
+const ReviewWindow = 14 * 24 * time.Hour
+
+// ReviewCategories — оценки гостя по категориям, каждая от 1 до 5
+var ReviewCategories = []string{"cleanliness", "accuracy", "communication", "location", "check_in", "value"}
+
+func IsReviewCategory(name string) bool {
+	for _, c := range ReviewCategories {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Review — отзыв по завершённой брони. PublishedAt пуст, пока отзыв скрыт (double-blind)
+type Review struct {
+	ID          string     `gorm:"column:review_id;type:uuid;primaryKey"`
+	BookingID   string     `gorm:"column:booking_id;not null;uniqueIndex:idx_reviews_booking_kind"`
+	Kind        string     `gorm:"column:kind;not null;uniqueIndex:idx_reviews_booking_kind"`
+	ApartmentID string     `gorm:"column:ap_id;type:uuid;not null;index"`
+	AuthorID    string     `gorm:"column:author_id;type:uuid;not null"`
+	SubjectID   string     `gorm:"column:subject_id;type:uuid;not null;index"`
+	Overall     int        `gorm:"column:overall;not null"`
+	Categories  string     `gorm:"column:categories;type:jsonb;default:'{}';not null"`
+	Comment     string     `gorm:"column:comment"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	PublishedAt *time.Time `gorm:"column:published_at;type:timestamptz"`
+}
+
+func (Review) TableName() string {
+	return "reviews"
+}
+
+func (r *Review) CategoryMap() map[string]int {
+	out := map[string]int{}
+	if r.Categories != "" {
+		_ = json.Unmarshal([]byte(r.Categories), &out)
+	}
+	return out
+}
+
+func (r *Review) SetCategories(c map[string]int) {
+	if len(c) == 0 {
+		r.Categories = "{}"
+		return
+	}
+	raw, _ := json.Marshal(c)
+	r.Categories = string(raw)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StayRules — правила проживания апартамента. Нулевые значения (кроме MinNights) не ограничивают.
+// Дни заезда и выезда — маски дней недели: бит 1<<time.Weekday, 0 — любой день
+type StayRules struct {
+	ApartmentID    string    `gorm:"column:ap_id;type:uuid;primaryKey"`
+	MinNights      int       `gorm:"column:min_nights;default:1;not null"`
+	MaxNights      int       `gorm:"column:max_nights;default:0;not null"`
+	CheckInDays    int       `gorm:"column:check_in_days;default:0;not null"`
+	CheckOutDays   int       `gorm:"column:check_out_days;default:0;not null"`
+	CheckInTime    string    `gorm:"column:check_in_time;size:5"` // "15:00"; пусто — в любое время
+	CheckOutTime   string    `gorm:"column:check_out_time;size:5"`
+	MinNoticeHours int       `gorm:"column:min_notice_hours;default:0;not null"`
+	MaxHorizonDays int       `gorm:"column:max_horizon_days;default:0;not null"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;type:timestamptz;default:now();not null"`
+}
+
+func (StayRules) TableName() string {
+	return "apartment_stay_rules"
+}
+
+// DefaultStayRules — правила апартамента, для которого хост их не задавал
+func DefaultStayRules(apID string) StayRules {
+	return StayRules{ApartmentID: apID, MinNights: 1}
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// WeekdayMask переводит список дней ("mon", "fri") в маску
+func WeekdayMask(days []string) (int, error) {
+	mask := 0
+	for _, day := range days {
+		i := indexOf(weekdayNames, strings.ToLower(strings.TrimSpace(day)))
+		if i < 0 {
+			return 0, fmt.Errorf("unknown weekday %q", day)
+		}
+		mask |= 1 << i
+	}
+	return mask, nil
+}
+
+// WeekdayNames — дни маски по порядку недели; для пустой маски пустой список
+func WeekdayNames(mask int) []string {
+	out := []string{}
+	for i, name := range weekdayNames {
+		if mask&(1<<i) != 0 {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func AllowsWeekday(mask int, day time.Weekday) bool {
+	return mask == 0 || mask&(1<<int(day)) != 0
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
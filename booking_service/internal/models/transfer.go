@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	TransferPending   = "pending"
+	TransferAccepted  = "accepted"
+	TransferDeclined  = "declined"
+	TransferCancelled = "cancelled"
+)
+
+// OwnershipTransfer — запрос на передачу объявления другому хосту; после решения
+// строка остаётся как запись аудита
+type OwnershipTransfer struct {
+	ID          string     `gorm:"column:transfer_id;type:uuid;primaryKey"`
+	ApartmentID string     `gorm:"column:ap_id;type:uuid;index;not null"`
+	FromUserID  string     `gorm:"column:from_user_id;type:uuid;index;not null"`
+	ToUserID    string     `gorm:"column:to_user_id;type:uuid;index;not null"`
+	Status      string     `gorm:"column:status;default:'pending';not null"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	ResolvedAt  *time.Time `gorm:"column:resolved_at;type:timestamptz"`
+	ResolvedBy  *string    `gorm:"column:resolved_by;type:uuid"`
+
+	Apartment Apartment `gorm:"foreignKey:ApartmentID;references:ID"`
+}
+
+func (OwnershipTransfer) TableName() string {
+	return "ownership_transfers"
+}
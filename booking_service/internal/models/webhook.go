@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookSubscription — endpoint хоста или интегратора; события отбираются по владельцу
+// апартамента и списку типов. Secret используется для HMAC-подписи доставок
+type WebhookSubscription struct {
+	ID                  string     `gorm:"column:webhook_id;type:uuid;primaryKey"`
+	OwnerID             string     `gorm:"column:owner_id;type:uuid;index;not null"`
+	URL                 string     `gorm:"column:url;not null"`
+	EventTypes          string     `gorm:"column:event_types;type:jsonb;default:'[]';not null"`
+	Secret              string     `gorm:"column:secret;not null"`
+	Active              bool       `gorm:"column:active;default:true;not null"`
+	ConsecutiveFailures int        `gorm:"column:consecutive_failures;default:0;not null"`
+	DisabledAt          *time.Time `gorm:"column:disabled_at;type:timestamptz"`
+	CreatedAt           time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (w *WebhookSubscription) EventTypeList() []string {
+	out := []string{}
+	if w.EventTypes != "" {
+		_ = json.Unmarshal([]byte(w.EventTypes), &out)
+	}
+	return out
+}
+
+// WebhookDelivery — одна доставка события на один endpoint; служит журналом и очередью повторов
+type WebhookDelivery struct {
+	ID             string     `gorm:"column:delivery_id;type:uuid;primaryKey"`
+	SubscriptionID string     `gorm:"column:webhook_id;type:uuid;not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventID        string     `gorm:"column:event_id;type:uuid;not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventType      string     `gorm:"column:event_type;not null"`
+	Body           string     `gorm:"column:body;type:jsonb;not null"`
+	Status         string     `gorm:"column:status;default:'pending';not null"`
+	Attempts       int        `gorm:"column:attempts;default:0;not null"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;type:timestamptz;default:now();not null"`
+	LastStatusCode *int       `gorm:"column:last_status_code"`
+	LastError      string     `gorm:"column:last_error"`
+	CreatedAt      time.Time  `gorm:"column:created_at;type:timestamptz;default:now();not null"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at;type:timestamptz"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Время заезда и выезда, если хост не задал своё в правилах проживания
+const (
+	DefaultCheckInTime  = "15:00"
+	DefaultCheckOutTime = "11:00"
+)
+
+// LoadZone — часовой пояс по имени IANA; пустое или неизвестное имя — UTC
+func LoadZone(name string) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,59 @@
+package money
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency — валюта объявлений, созданных без явной валюты (и всех объявлений до её появления)
+const DefaultCurrency = "EUR"
+
+// currencies — поддерживаемые валюты ISO 4217 и число знаков после запятой у каждой
+var currencies = map[string]int32{
+	"EUR": 2,
+	"USD": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"HUF": 2,
+	"PLN": 2,
+	"CZK": 2,
+	"SEK": 2,
+	"NOK": 2,
+	"DKK": 2,
+	"TRY": 2,
+	"RUB": 2,
+	"JPY": 0,
+}
+
+// Normalize приводит код валюты к верхнему регистру; пустая строка остаётся пустой
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func IsSupported(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// Exponent — число знаков после запятой в валюте (2 для евро, 0 для иены)
+func Exponent(code string) int32 {
+	if exp, ok := currencies[code]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Round округляет сумму до минимальной единицы валюты, половину — от нуля
+func Round(amount decimal.Decimal, code string) decimal.Decimal {
+	return amount.Round(Exponent(code))
+}
+
+// ToMinor переводит сумму в минимальные единицы (центы), как их ждут провайдеры платежей
+func ToMinor(amount decimal.Decimal, code string) int64 {
+	return amount.Shift(Exponent(code)).Round(0).IntPart()
+}
+
+func FromMinor(amount int64, code string) decimal.Decimal {
+	return decimal.New(amount, -Exponent(code))
+}
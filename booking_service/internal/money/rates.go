@@ -0,0 +1,137 @@
+package money
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrUnknownRate = errors.New("no exchange rate for currency")
+
+// Rates — курсы к базовой валюте: 1 Base = Values[code] code
+type Rates struct {
+	Base   string                     `json:"base"`
+	AsOf   time.Time                  `json:"as_of"`
+	Values map[string]decimal.Decimal `json:"rates"`
+}
+
+func (r *Rates) rate(code string) (decimal.Decimal, error) {
+	if code == r.Base {
+		return decimal.NewFromInt(1), nil
+	}
+	v, ok := r.Values[code]
+	if !ok || !v.IsPositive() {
+		return decimal.Decimal{}, fmt.Errorf("%w %s", ErrUnknownRate, code)
+	}
+	return v, nil
+}
+
+// RateSource — откуда берутся курсы валют
+type RateSource interface {
+	Rates(ctx context.Context) (Rates, error)
+}
+
+//go:embed rates.json
+var defaultRates []byte
+
+// StaticFileSource читает курсы из JSON-файла вида {"base": "EUR", "as_of": "...", "rates": {"USD": "1.08"}}.
+// Без пути используются курсы, вшитые в сборку, — так сервис работает без сети
+type StaticFileSource struct {
+	path string
+}
+
+func NewStaticFileSource(path string) *StaticFileSource {
+	return &StaticFileSource{path: path}
+}
+
+func (s *StaticFileSource) Rates(_ context.Context) (Rates, error) {
+	raw := defaultRates
+	if s.path != "" {
+		var err error
+		if raw, err = os.ReadFile(s.path); err != nil {
+			return Rates{}, err
+		}
+	}
+
+	var r Rates
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return Rates{}, fmt.Errorf("invalid rates file: %w", err)
+	}
+	r.Base = Normalize(r.Base)
+	if r.Base == "" {
+		return Rates{}, errors.New("invalid rates file: base currency is required")
+	}
+	return r, nil
+}
+
+// Converter пересчитывает суммы по курсам источника, перечитывая их раз в refresh.
+// Если источник недоступен, используются последние полученные курсы
+type Converter struct {
+	source  RateSource
+	refresh time.Duration
+
+	mu        sync.Mutex
+	rates     *Rates
+	fetchedAt time.Time
+}
+
+func NewConverter(source RateSource, refresh time.Duration) *Converter {
+	return &Converter{source: source, refresh: refresh}
+}
+
+func (c *Converter) current(ctx context.Context) (*Rates, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rates != nil && time.Since(c.fetchedAt) < c.refresh {
+		return c.rates, nil
+	}
+
+	r, err := c.source.Rates(ctx)
+	if err != nil {
+		if c.rates != nil {
+			return c.rates, nil
+		}
+		return nil, err
+	}
+	c.rates = &r
+	c.fetchedAt = time.Now()
+	return c.rates, nil
+}
+
+// Rate — сколько единиц to дают за одну единицу from
+func (c *Converter) Rate(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	r, err := c.current(ctx)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	fromRate, err := r.rate(from)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	toRate, err := r.rate(to)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return toRate.Div(fromRate), nil
+}
+
+// Convert пересчитывает сумму и округляет её до минимальной единицы целевой валюты
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	rate, err := c.Rate(ctx, from, to)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return Round(amount.Mul(rate), to), nil
+}
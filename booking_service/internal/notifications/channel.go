@@ -0,0 +1,54 @@
+package notifications
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+type Message struct {
+	UserID   string
+	Template string
+	Subject  string
+	Body     string
+}
+
+// Channel — внешний канал доставки. In-app уведомления каналом не являются:
+// это строки notifications, которые клиент читает через API
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, m Message) error
+}
+
+// LogChannel — локальная заглушка канала: сообщение только пишется в лог
+type LogChannel struct {
+	name string
+}
+
+func NewFakeEmail() *LogChannel {
+	return &LogChannel{name: ChannelEmail}
+}
+
+func NewFakePush() *LogChannel {
+	return &LogChannel{name: ChannelPush}
+}
+
+func (c *LogChannel) Name() string {
+	return c.name
+}
+
+func (c *LogChannel) Send(_ context.Context, m Message) error {
+	logrus.WithField("Time", time.Now().String()).WithFields(logrus.Fields{
+		"channel":  c.name,
+		"user_id":  m.UserID,
+		"template": m.Template,
+		"subject":  m.Subject,
+	}).Info(m.Body)
+	return nil
+}
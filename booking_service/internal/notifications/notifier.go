@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notifier превращает доменные события в уведомления гостям и хостам.
+// Подключается к events.Relay как ещё один events.Publisher
+type Notifier struct {
+	db       *gorm.DB
+	channels []Channel
+}
+
+func NewNotifier(db *gorm.DB, channels ...Channel) *Notifier {
+	return &Notifier{db: db, channels: channels}
+}
+
+func (n *Notifier) Publish(ctx context.Context, e events.Event) error {
+	if e.Type != events.BookingConfirmed && e.Type != events.BookingCancelled {
+		return nil
+	}
+
+	var p events.BookingPayload
+	if err := json.Unmarshal(e.Payload, &p); err != nil {
+		return err
+	}
+
+	data, err := n.bookingData(ctx, &p)
+	if err != nil {
+		return err
+	}
+
+	if e.Type == events.BookingConfirmed {
+		if err := n.Notify(ctx, p.UserID, BookingConfirmed, p.ID, data); err != nil {
+			return err
+		}
+		return n.Notify(ctx, p.OwnerID, BookingReceived, p.ID, data)
+	}
+
+	if err := n.Notify(ctx, p.UserID, BookingCancelled, p.ID, data); err != nil {
+		return err
+	}
+	return n.Notify(ctx, p.OwnerID, BookingCancelled, p.ID, data)
+}
+
+func (n *Notifier) bookingData(ctx context.Context, p *events.BookingPayload) (Data, error) {
+	var ap models.Apartment
+	if err := n.db.WithContext(ctx).Select("id", "address", "time_zone").Where("id = ?", p.ApartmentID).First(&ap).Error; err != nil {
+		return Data{}, err
+	}
+	return stayData(p.ID, &ap, p.TimeFrom, p.TimeTo), nil
+}
+
+// stayData — заезд и выезд в местном времени апартамента, а не в поясе сервера
+func stayData(bookingID string, ap *models.Apartment, from, to time.Time) Data {
+	loc := ap.Location()
+	return Data{BookingID: bookingID, Address: ap.Address, TimeFrom: from.In(loc), TimeTo: to.In(loc)}
+}
+
+// Notify отправляет уведомление один раз на (refID, userID, name): повторный вызов
+// с теми же ключами ничего не делает. Внешние каналы — не более одной попытки,
+// их ошибки только логируются
+func (n *Notifier) Notify(ctx context.Context, userID, name, refID string, data Data) error {
+	prefs := models.DefaultNotificationPreference(userID)
+	err := n.db.WithContext(ctx).Where("user_id = ?", userID).First(&prefs).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	subject, body, err := Render(name, prefs.Locale, data)
+	if err != nil {
+		return err
+	}
+
+	res := n.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Notification{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Template:  name,
+		RefID:     refID,
+		Subject:   subject,
+		Body:      body,
+		InApp:     prefs.InApp,
+		CreatedAt: time.Now(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+
+	msg := Message{UserID: userID, Template: name, Subject: subject, Body: body}
+	for _, ch := range n.channels {
+		if !prefs.Allows(ch.Name()) {
+			continue
+		}
+		if err := ch.Send(ctx, msg); err != nil {
+			logrus.WithError(err).Warnf("Notification %s to %s via %s failed", name, userID, ch.Name())
+		}
+	}
+	return nil
+}
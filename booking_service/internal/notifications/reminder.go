@@ -0,0 +1,59 @@
+package notifications
+
+import (
+	"booking_service/internal/models"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reminder периодически напоминает гостям о заезде, который наступит в ближайшие lead
+type Reminder struct {
+	notifier *Notifier
+	lead     time.Duration
+	interval time.Duration
+}
+
+func NewReminder(n *Notifier, lead, interval time.Duration) *Reminder {
+	return &Reminder{notifier: n, lead: lead, interval: interval}
+}
+
+func (r *Reminder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.remind(ctx); err != nil {
+			logrus.WithError(err).Warn("Check-in reminders failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reminder) remind(ctx context.Context) error {
+	now := time.Now()
+
+	var due []models.Booking
+	if err := r.notifier.db.WithContext(ctx).
+		Preload("Apartment").
+		Where("bookings.status = ? AND time_from > ? AND time_from <= ?", models.BookingConfirmed, now, now.Add(r.lead)).
+		Where(`NOT EXISTS (SELECT 1 FROM notifications n
+			WHERE n.ref_id = bookings.booking_id AND n.user_id = bookings.user_id AND n.template = ?)`, CheckinReminder).
+		Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, b := range due {
+		data := stayData(b.ID, &b.Apartment, b.TimeFrom, b.TimeTo)
+		if err := r.notifier.Notify(ctx, b.UserID, CheckinReminder, b.ID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
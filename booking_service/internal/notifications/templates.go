@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+const (
+	BookingConfirmed = "booking_confirmed" // гостю после авторизации платежа
+	BookingReceived  = "booking_received"  // хосту о новой брони
+	CheckinReminder  = "checkin_reminder"  // гостю накануне заезда
+	BookingCancelled = "booking_cancelled" // обеим сторонам
+)
+
+const defaultLocale = "en"
+
+// Data — поля, доступные в шаблонах; TimeFrom и TimeTo — в поясе апартамента
+type Data struct {
+	BookingID string
+	Address   string
+	TimeFrom  time.Time
+	TimeTo    time.Time
+}
+
+type localized struct {
+	subject string
+	body    string
+}
+
+var dateLayouts = map[string]string{
+	"en": "Jan 2, 2006 15:04",
+	"ru": "02.01.2006 15:04",
+}
+
+var sources = map[string]map[string]localized{
+	BookingConfirmed: {
+		"en": {"Your booking is confirmed", "Your stay at {{.Address}} from {{date .TimeFrom}} to {{date .TimeTo}} is confirmed. Booking {{.BookingID}}."},
+		"ru": {"Бронирование подтверждено", "Ваше проживание по адресу {{.Address}} с {{date .TimeFrom}} по {{date .TimeTo}} подтверждено. Бронь {{.BookingID}}."},
+	},
+	BookingReceived: {
+		"en": {"New booking", "{{.Address}} has been booked from {{date .TimeFrom}} to {{date .TimeTo}}. Booking {{.BookingID}}."},
+		"ru": {"Новое бронирование", "{{.Address}} забронирован с {{date .TimeFrom}} по {{date .TimeTo}}. Бронь {{.BookingID}}."},
+	},
+	CheckinReminder: {
+		"en": {"Check-in is coming up", "Your check-in at {{.Address}} is on {{date .TimeFrom}}."},
+		"ru": {"Скоро заезд", "Заезд по адресу {{.Address}} — {{date .TimeFrom}}."},
+	},
+	BookingCancelled: {
+		"en": {"Booking cancelled", "The booking {{.BookingID}} at {{.Address}} for {{date .TimeFrom}} – {{date .TimeTo}} has been cancelled."},
+		"ru": {"Бронирование отменено", "Бронь {{.BookingID}} по адресу {{.Address}} на {{date .TimeFrom}} – {{date .TimeTo}} отменена."},
+	},
+}
+
+// compiled[name][locale] — [subject, body]
+var compiled = map[string]map[string][2]*template.Template{}
+
+func init() {
+	for name, locales := range sources {
+		compiled[name] = map[string][2]*template.Template{}
+		for locale, src := range locales {
+			layout := dateLayouts[locale]
+			funcs := template.FuncMap{"date": func(t time.Time) string { return t.Format(layout) }}
+			compiled[name][locale] = [2]*template.Template{
+				template.Must(template.New(name + ".subject").Funcs(funcs).Parse(src.subject)),
+				template.Must(template.New(name + ".body").Funcs(funcs).Parse(src.body)),
+			}
+		}
+	}
+}
+
+// Render подставляет данные в шаблон на языке пользователя; неизвестный язык — английский
+func Render(name, locale string, data Data) (subject, body string, err error) {
+	locales, ok := compiled[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown notification template '%s'", name)
+	}
+	tpl, ok := locales[locale]
+	if !ok {
+		tpl = locales[defaultLocale]
+	}
+
+	var s, b bytes.Buffer
+	if err := tpl[0].Execute(&s, data); err != nil {
+		return "", "", err
+	}
+	if err := tpl[1].Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	return s.String(), b.String(), nil
+}
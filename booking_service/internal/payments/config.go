@@ -0,0 +1,21 @@
+package payments
+
+import (
+	"booking_service/internal/config"
+	"fmt"
+)
+
+// NewFromConfig выбирает провайдера по BOOKING_PAYMENTS_PROVIDER
+func NewFromConfig(cfg *config.Config) (PaymentProvider, error) {
+	switch cfg.PAYMENTS_PROVIDER {
+	case "fake":
+		return NewFakeProvider(cfg.PAYMENTS_WEBHOOK_SECRET), nil
+	case "stripe":
+		if cfg.STRIPE_SECRET_KEY == "" || cfg.STRIPE_WEBHOOK_SECRET == "" {
+			return nil, fmt.Errorf("BOOKING_STRIPE_SECRET_KEY and BOOKING_STRIPE_WEBHOOK_SECRET are required for stripe provider")
+		}
+		return NewStripeProvider(cfg.STRIPE_API_URL, cfg.STRIPE_SECRET_KEY, cfg.STRIPE_WEBHOOK_SECRET), nil
+	default:
+		return nil, fmt.Errorf("unknown payments provider '%s'", cfg.PAYMENTS_PROVIDER)
+	}
+}
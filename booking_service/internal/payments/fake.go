@@ -0,0 +1,176 @@
+package payments
+
+import (
+	"booking_service/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Тестовые способы оплаты FakeProvider
+const (
+	FakeCardOK       = "pm_card_ok"
+	FakeCardDeclined = "pm_card_declined"
+	FakeCardAsync    = "pm_card_async" // остаётся pending до вебхука
+)
+
+type fakePayment struct {
+	status   string
+	amount   int64
+	captured int64
+	refunded int64
+}
+
+// FakeProvider — провайдер в памяти для локальной разработки. Асинхронные платежи
+// завершаются POST /payments/webhooks/fake с телом {"id", "ref", "status", "reason"}
+type FakeProvider struct {
+	secret string
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	keys     map[string]Result // ответы по ключам идемпотентности
+}
+
+// NewFakeProvider: при пустом secret подпись вебхуков не проверяется
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret:   secret,
+		payments: map[string]*fakePayment{},
+		keys:     map[string]Result{},
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Authorize(_ context.Context, req AuthorizeRequest) (Result, error) {
+	return p.once(req.PaymentID+":authorize", func() (Result, error) {
+		ref := "fake_" + uuid.New().String()
+		fp := &fakePayment{amount: req.Amount}
+		p.payments[ref] = fp
+
+		switch req.Method {
+		case "", FakeCardOK:
+			fp.status = models.PaymentAuthorized
+			return Result{Ref: ref, Status: fp.status}, nil
+		case FakeCardAsync:
+			fp.status = models.PaymentPending
+			return Result{Ref: ref, Status: fp.status, ClientSecret: ref + "_secret"}, nil
+		default:
+			fp.status = models.PaymentFailed
+			return Result{Ref: ref, Status: fp.status, Reason: "card declined"}, nil
+		}
+	})
+}
+
+func (p *FakeProvider) Capture(_ context.Context, ref string, amount int64, idempotencyKey string) (Result, error) {
+	return p.once(idempotencyKey, func() (Result, error) {
+		fp, err := p.get(ref)
+		if err != nil {
+			return Result{}, err
+		}
+		if fp.status != models.PaymentAuthorized {
+			return Result{}, fmt.Errorf("fake: cannot capture %s payment", fp.status)
+		}
+		if amount > fp.amount {
+			return Result{}, fmt.Errorf("fake: capture exceeds authorized amount")
+		}
+		fp.status = models.PaymentCaptured
+		fp.captured = amount
+		return Result{Ref: ref, Status: fp.status}, nil
+	})
+}
+
+func (p *FakeProvider) Void(_ context.Context, ref string, idempotencyKey string) (Result, error) {
+	return p.once(idempotencyKey, func() (Result, error) {
+		fp, err := p.get(ref)
+		if err != nil {
+			return Result{}, err
+		}
+		if fp.status != models.PaymentPending && fp.status != models.PaymentAuthorized {
+			return Result{}, fmt.Errorf("fake: cannot void %s payment", fp.status)
+		}
+		fp.status = models.PaymentVoided
+		return Result{Ref: ref, Status: fp.status}, nil
+	})
+}
+
+func (p *FakeProvider) Refund(_ context.Context, ref string, amount int64, idempotencyKey string) (Result, error) {
+	return p.once(idempotencyKey, func() (Result, error) {
+		fp, err := p.get(ref)
+		if err != nil {
+			return Result{}, err
+		}
+		if fp.status != models.PaymentCaptured || fp.refunded+amount > fp.captured {
+			return Result{}, fmt.Errorf("fake: cannot refund %d of %s payment", amount, fp.status)
+		}
+		fp.refunded += amount
+		if fp.refunded == fp.captured {
+			fp.status = models.PaymentRefunded
+		}
+		return Result{Ref: ref, Status: fp.status}, nil
+	})
+}
+
+func (p *FakeProvider) Lookup(_ context.Context, ref string) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fp, err := p.get(ref)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Ref: ref, Status: fp.status}, nil
+}
+
+func (p *FakeProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if p.secret != "" && !verifySignature(header.Get("X-Fake-Signature"), p.secret, body) {
+		return nil, ErrInvalidSignature
+	}
+
+	var we WebhookEvent
+	if err := json.Unmarshal(body, &we); err != nil {
+		return nil, err
+	}
+	if we.ID == "" || we.Ref == "" {
+		return nil, fmt.Errorf("fake: id and ref are required")
+	}
+
+	// имитируем то, что провайдер сделал на своей стороне
+	p.mu.Lock()
+	if fp, ok := p.payments[we.Ref]; ok {
+		fp.status = we.Status
+	}
+	p.mu.Unlock()
+
+	return &we, nil
+}
+
+func (p *FakeProvider) once(key string, op func() (Result, error)) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r, ok := p.keys[key]; ok {
+		return r, nil
+	}
+	r, err := op()
+	if err != nil {
+		return Result{}, err
+	}
+	p.keys[key] = r
+	return r, nil
+}
+
+// get вызывается под p.mu
+func (p *FakeProvider) get(ref string) (*fakePayment, error) {
+	fp, ok := p.payments[ref]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	return fp, nil
+}
@@ -0,0 +1,237 @@
+package payments
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/repository"
+	servererrors "booking_service/internal/server_errors"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	reconcileBatch = 50
+	// reconcileGrace — не трогаем платежи, с которыми прямо сейчас может работать запрос
+	reconcileGrace = time.Minute
+)
+
+// Processor связывает брони с провайдером: авторизация при бронировании, списание после
+// подтверждения, возврат при отмене. Любой шаг можно безопасно повторить — так и делает сверка
+type Processor struct {
+	repo          repository.Repository
+	provider      PaymentProvider
+	commissionBps int
+	holdTTL       time.Duration
+}
+
+// NewProcessor: commissionBps — комиссия платформы в сотых процента,
+// holdTTL — сколько бронь держит даты, ожидая авторизации
+func NewProcessor(repo repository.Repository, provider PaymentProvider, commissionBps int, holdTTL time.Duration) *Processor {
+	return &Processor{repo: repo, provider: provider, commissionBps: commissionBps, holdTTL: holdTTL}
+}
+
+func (p *Processor) ProviderName() string {
+	return p.provider.Name()
+}
+
+// Book создаёт бронь и сразу пытается авторизовать оплату. Если провайдер недоступен или
+// ждёт подтверждения клиента, бронь остаётся pending_payment — её завершит вебхук или сверка
+func (p *Processor) Book(ctx context.Context, dto *dtos.BookingCreateDTO) (models.Booking, Result, error) {
+	booking, err := p.repo.CreateBooking(dto, &models.Payment{
+		Provider:      p.provider.Name(),
+		CommissionBps: p.commissionBps,
+	})
+	if err != nil {
+		return models.Booking{}, Result{}, err
+	}
+	payment := booking.Payment
+
+	res, err := p.provider.Authorize(ctx, AuthorizeRequest{
+		PaymentID: payment.ID,
+		BookingID: booking.ID,
+		Amount:    payment.Amount,
+		Currency:  payment.Currency,
+		Method:    dto.PaymentMethod,
+	})
+	if err != nil {
+		logrus.WithError(err).Warnf("Authorization of payment %s failed, left for reconciliation", payment.ID)
+		return booking, Result{Status: models.PaymentPending}, nil
+	}
+
+	updated, err := p.apply(*payment, res, "")
+	if err != nil {
+		return models.Booking{}, Result{}, err
+	}
+	updated = p.settle(ctx, updated)
+
+	lines := booking.LineItems
+	booking = updated.Booking
+	booking.Payment = &updated
+	booking.LineItems = lines
+	return booking, res, nil
+}
+
+// Cancel возвращает деньги или снимает авторизацию после отмены брони
+func (p *Processor) Cancel(ctx context.Context, bookingID string) (models.Payment, error) {
+	payment, err := p.repo.GetBookingPayment(bookingID)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	return p.settle(ctx, payment), nil
+}
+
+// HandleWebhook применяет уведомление провайдера. Неизвестные платежи подтверждаются
+// без ошибки, чтобы провайдер не повторял их бесконечно
+func (p *Processor) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	ev, err := p.provider.ParseWebhook(header, body)
+	if err != nil || ev == nil {
+		return err
+	}
+
+	payment, err := p.repo.ApplyPaymentUpdate(&dtos.PaymentUpdateDTO{
+		PaymentID:   ev.PaymentID,
+		Provider:    p.provider.Name(),
+		ProviderRef: ev.Ref,
+		Status:      ev.Status,
+		Reason:      ev.Reason,
+		EventID:     ev.ID,
+	})
+	if err != nil {
+		var nfe *servererrors.NotFoundError
+		if errors.As(err, &nfe) {
+			logrus.Warnf("Webhook %s for unknown payment %s ignored", ev.ID, ev.Ref)
+			return nil
+		}
+		return err
+	}
+
+	p.release(ctx, &payment, ev.Status, ev.Ref)
+	p.settle(ctx, payment)
+	return nil
+}
+
+// Reconcile догоняет платежи, по которым не пришёл вебхук или не удался следующий шаг
+func (p *Processor) Reconcile(ctx context.Context) (int, error) {
+	payments, err := p.repo.GetPaymentsToReconcile(time.Now().Add(-reconcileGrace), reconcileBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, payment := range payments {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if payment.Status == models.PaymentPending && payment.ProviderRef != "" {
+			res, err := p.provider.Lookup(ctx, payment.ProviderRef)
+			if err != nil {
+				logrus.WithError(err).Warnf("Lookup of payment %s failed", payment.ID)
+				continue
+			}
+			if payment, err = p.apply(payment, res, ""); err != nil {
+				return 0, err
+			}
+		}
+
+		// авторизация так и не пришла — отпускаем даты
+		if payment.Status == models.PaymentPending && time.Since(payment.CreatedAt) > p.holdTTL {
+			if payment.ProviderRef != "" {
+				if _, err := p.provider.Void(ctx, payment.ProviderRef, payment.ID+":expire"); err != nil {
+					logrus.WithError(err).Warnf("Void of expired payment %s failed", payment.ID)
+					continue
+				}
+			}
+			res := Result{Ref: payment.ProviderRef, Status: models.PaymentFailed, Reason: "payment was not authorized in time"}
+			if payment, err = p.apply(payment, res, ""); err != nil {
+				return 0, err
+			}
+		}
+
+		p.settle(ctx, payment)
+	}
+	return len(payments), nil
+}
+
+func (p *Processor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Reconcile(ctx); err != nil {
+			logrus.WithError(err).Warn("Payment reconciliation failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Processor) apply(payment models.Payment, res Result, eventID string) (models.Payment, error) {
+	return p.repo.ApplyPaymentUpdate(&dtos.PaymentUpdateDTO{
+		PaymentID:   payment.ID,
+		ProviderRef: res.Ref,
+		Status:      res.Status,
+		Reason:      res.Reason,
+		EventID:     eventID,
+	})
+}
+
+// settle делает следующий шаг по состоянию платежа и брони. Ошибки провайдера только
+// логируются: шаг повторит сверка
+func (p *Processor) settle(ctx context.Context, payment models.Payment) models.Payment {
+	booking := &payment.Booking
+	active := booking.Status == models.BookingConfirmed || booking.Status == models.BookingPendingPayment
+
+	var res Result
+	var err error
+	switch {
+	case payment.Status == models.PaymentAuthorized && booking.Status == models.BookingConfirmed:
+		res, err = p.provider.Capture(ctx, payment.ProviderRef, payment.Amount, payment.ID+":capture")
+	case payment.Status == models.PaymentAuthorized && !active,
+		payment.Status == models.PaymentPending && payment.ProviderRef != "" && !active:
+		res, err = p.provider.Void(ctx, payment.ProviderRef, payment.ID+":void")
+	case payment.Status == models.PaymentCaptured && !active:
+		res, err = p.provider.Refund(ctx, payment.ProviderRef, payment.Amount-payment.RefundedAmount, payment.ID+":refund")
+	default:
+		return payment
+	}
+	if err != nil {
+		logrus.WithError(err).Warnf("Settling payment %s (%s, booking %s) failed", payment.ID, payment.Status, booking.Status)
+		return payment
+	}
+
+	updated, err := p.apply(payment, res, "")
+	if err != nil {
+		logrus.WithError(err).Warnf("Saving payment %s after settling failed", payment.ID)
+		return payment
+	}
+	return updated
+}
+
+// release — провайдер сообщил об успехе по платежу, который у нас уже закрыт
+// (например, авторизация пришла после истечения удержания): деньги возвращаются
+func (p *Processor) release(ctx context.Context, payment *models.Payment, reported, ref string) {
+	if payment.Status != models.PaymentFailed && payment.Status != models.PaymentVoided {
+		return
+	}
+
+	var err error
+	switch reported {
+	case models.PaymentAuthorized:
+		_, err = p.provider.Void(ctx, ref, payment.ID+":release")
+	case models.PaymentCaptured:
+		_, err = p.provider.Refund(ctx, ref, payment.Amount, payment.ID+":release")
+	default:
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Warnf("Releasing late %s payment %s failed", reported, payment.ID)
+	}
+}
@@ -0,0 +1,55 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownPayment   = errors.New("unknown payment")
+)
+
+// AuthorizeRequest — холдирование суммы брони. PaymentID служит ключом идемпотентности:
+// повторный вызов с тем же PaymentID не создаёт второй платёж у провайдера
+type AuthorizeRequest struct {
+	PaymentID string
+	BookingID string
+	Amount    int64 // в минимальных единицах валюты
+	Currency  string
+	Method    string // токен способа оплаты у провайдера
+}
+
+// Result — состояние платежа у провайдера, Status — одна из models.Payment*
+type Result struct {
+	Ref          string
+	Status       string
+	Reason       string
+	ClientSecret string // для подтверждения клиентом (3-D Secure), если платёж ждёт действия
+}
+
+// WebhookEvent — асинхронное уведомление провайдера о смене состояния платежа
+// PaymentID — наш идентификатор из метаданных платежа, если провайдер его вернул
+type WebhookEvent struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	Ref       string `json:"ref"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+// PaymentProvider — внешний платёжный провайдер. Все операции идемпотентны по idempotencyKey,
+// поэтому их можно безопасно повторять после сетевых ошибок
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (Result, error)
+	Capture(ctx context.Context, ref string, amount int64, idempotencyKey string) (Result, error)
+	// Void снимает авторизацию, которая ещё не списана
+	Void(ctx context.Context, ref string, idempotencyKey string) (Result, error)
+	Refund(ctx context.Context, ref string, amount int64, idempotencyKey string) (Result, error)
+	// Lookup — текущее состояние платежа, для сверки, если вебхук потерялся
+	Lookup(ctx context.Context, ref string) (Result, error)
+	// ParseWebhook проверяет подпись и разбирает уведомление; nil без ошибки — событие нам не интересно
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
@@ -0,0 +1,47 @@
+package payments
+
+import (
+	"booking_service/internal/webhooks"
+	"crypto/hmac"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signatureTolerance — насколько старую подпись ещё принимаем (защита от воспроизведения)
+const signatureTolerance = 5 * time.Minute
+
+// verifySignature проверяет заголовок вида "t=<timestamp>,v1=<hex>[,v1=<hex>...]" —
+// та же схема, которой подписываются наши исходящие вебхуки
+func verifySignature(header, secret string, body []byte) bool {
+	var ts int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts, _ = strconv.ParseInt(v, 10, 64)
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	if ts == 0 || len(signatures) == 0 {
+		return false
+	}
+
+	age := time.Since(time.Unix(ts, 0))
+	if age > signatureTolerance || age < -signatureTolerance {
+		return false
+	}
+
+	_, expected, _ := strings.Cut(webhooks.Sign(secret, ts, body), ",v1=")
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package payments
+
+import (
+	"booking_service/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StripeProvider работает с Payment Intents в режиме ручного списания (capture_method=manual):
+// Authorize создаёт и подтверждает intent, Capture списывает удержанную сумму
+type StripeProvider struct {
+	baseURL       string
+	secretKey     string
+	webhookSecret string
+	client        *http.Client
+}
+
+func NewStripeProvider(baseURL, secretKey, webhookSecret string) *StripeProvider {
+	return &StripeProvider{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+// stripeObject — общие поля payment_intent, refund и charge, которые нам нужны
+type stripeObject struct {
+	ID               string `json:"id"`
+	Object           string `json:"object"`
+	Status           string `json:"status"`
+	ClientSecret     string `json:"client_secret"`
+	PaymentIntent    string `json:"payment_intent"`
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+	FailureReason string `json:"failure_reason"`
+	Metadata      struct {
+		PaymentID string `json:"payment_id"`
+	} `json:"metadata"`
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *StripeProvider) Authorize(ctx context.Context, req AuthorizeRequest) (Result, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("capture_method", "manual")
+	form.Set("confirm", "true")
+	form.Set("payment_method", req.Method)
+	form.Set("metadata[booking_id]", req.BookingID)
+	form.Set("metadata[payment_id]", req.PaymentID)
+
+	obj, declined, err := p.post(ctx, "/v1/payment_intents", form, req.PaymentID+":authorize")
+	if err != nil {
+		return Result{}, err
+	}
+	if declined != "" {
+		return Result{Ref: obj.ID, Status: models.PaymentFailed, Reason: declined}, nil
+	}
+	return intentResult(obj), nil
+}
+
+func (p *StripeProvider) Capture(ctx context.Context, ref string, amount int64, idempotencyKey string) (Result, error) {
+	form := url.Values{}
+	form.Set("amount_to_capture", strconv.FormatInt(amount, 10))
+
+	obj, declined, err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(ref)+"/capture", form, idempotencyKey)
+	if err != nil {
+		return Result{}, err
+	}
+	if declined != "" {
+		return Result{Ref: ref, Status: models.PaymentFailed, Reason: declined}, nil
+	}
+	return intentResult(obj), nil
+}
+
+func (p *StripeProvider) Void(ctx context.Context, ref string, idempotencyKey string) (Result, error) {
+	form := url.Values{}
+	form.Set("cancellation_reason", "abandoned")
+
+	obj, _, err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(ref)+"/cancel", form, idempotencyKey)
+	if err != nil {
+		return Result{}, err
+	}
+	return intentResult(obj), nil
+}
+
+func (p *StripeProvider) Refund(ctx context.Context, ref string, amount int64, idempotencyKey string) (Result, error) {
+	form := url.Values{}
+	form.Set("payment_intent", ref)
+	form.Set("amount", strconv.FormatInt(amount, 10))
+
+	obj, _, err := p.post(ctx, "/v1/refunds", form, idempotencyKey)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch obj.Status {
+	case "succeeded":
+		return Result{Ref: ref, Status: models.PaymentRefunded}, nil
+	case "failed", "canceled":
+		return Result{}, fmt.Errorf("stripe refund %s %s: %s", obj.ID, obj.Status, obj.FailureReason)
+	default:
+		// pending: итог придёт вебхуком charge.refunded
+		return Result{Ref: ref, Status: models.PaymentCaptured}, nil
+	}
+}
+
+func (p *StripeProvider) Lookup(ctx context.Context, ref string) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/v1/payment_intents/"+url.PathEscape(ref), nil)
+	if err != nil {
+		return Result{}, err
+	}
+	req.SetBasicAuth(p.secretKey, "")
+
+	obj, _, err := p.do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	return intentResult(obj), nil
+}
+
+func (p *StripeProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if !verifySignature(header.Get("Stripe-Signature"), p.webhookSecret, body) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object stripeObject `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	obj := &event.Data.Object
+	we := &WebhookEvent{ID: event.ID, PaymentID: obj.Metadata.PaymentID, Ref: obj.ID}
+	switch event.Type {
+	case "payment_intent.amount_capturable_updated":
+		we.Status = models.PaymentAuthorized
+	case "payment_intent.succeeded":
+		we.Status = models.PaymentCaptured
+	case "payment_intent.payment_failed":
+		we.Status = models.PaymentFailed
+		if obj.LastPaymentError != nil {
+			we.Reason = obj.LastPaymentError.Message
+		}
+	case "payment_intent.canceled":
+		we.Status = models.PaymentVoided
+	case "charge.refunded":
+		we.PaymentID = ""
+		we.Ref = obj.PaymentIntent
+		we.Status = models.PaymentRefunded
+	default:
+		return nil, nil
+	}
+	return we, nil
+}
+
+// intentResult переводит статус payment_intent в наш
+func intentResult(obj *stripeObject) Result {
+	r := Result{Ref: obj.ID, ClientSecret: obj.ClientSecret}
+	switch obj.Status {
+	case "requires_capture":
+		r.Status = models.PaymentAuthorized
+	case "succeeded":
+		r.Status = models.PaymentCaptured
+	case "canceled":
+		r.Status = models.PaymentVoided
+	case "requires_payment_method":
+		// после неудачной попытки intent возвращается в это состояние
+		if obj.LastPaymentError != nil {
+			r.Status = models.PaymentFailed
+			r.Reason = obj.LastPaymentError.Message
+		} else {
+			r.Status = models.PaymentPending
+		}
+	default:
+		// requires_action, requires_confirmation, processing
+		r.Status = models.PaymentPending
+	}
+	if r.Status != models.PaymentPending {
+		r.ClientSecret = ""
+	}
+	return r
+}
+
+func (p *StripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string) (*stripeObject, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, "", err
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	return p.do(req)
+}
+
+// do возвращает объект ответа; отказ банка (card_error) — не ошибка, а причина во втором значении
+func (p *StripeProvider) do(req *http.Request) (*stripeObject, string, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var se stripeError
+		_ = json.NewDecoder(resp.Body).Decode(&se)
+		if se.Error.Type == "card_error" {
+			return &stripeObject{}, se.Error.Message, nil
+		}
+		return nil, "", fmt.Errorf("stripe %s %s: %d %s", req.Method, req.URL.Path, resp.StatusCode, se.Error.Message)
+	}
+
+	var obj stripeObject
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return nil, "", err
+	}
+	return &obj, "", nil
+}
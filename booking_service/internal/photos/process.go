@@ -0,0 +1,138 @@
+package photos
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const MaxUploadSize = 10 << 20
+
+// MaxPixels — предел размера картинки. Сжатый файл в 10 МБ может объявить десятки тысяч
+// пикселей по каждой стороне, а декодер выделяет память под всю картинку сразу
+const MaxPixels = 50_000_000
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format, expected jpeg, png or webp")
+	ErrTooManyPixels     = errors.New("image exceeds 50 megapixels")
+)
+
+// ThumbnailSizes — имя размера и максимальная сторона в пикселях
+var ThumbnailSizes = []struct {
+	Name string
+	Max  int
+}{
+	{Name: "small", Max: 160},
+	{Name: "medium", Max: 480},
+	{Name: "large", Max: 1200},
+}
+
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+type Processed struct {
+	Original    []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Thumbnails  map[string][]byte // JPEG по именам из ThumbnailSizes
+}
+
+// Process проверяет формат загруженного файла и готовит миниатюры всех размеров
+func Process(r io.Reader) (*Processed, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > MaxUploadSize {
+		return nil, errors.New("image exceeds 10 MB")
+	}
+
+	contentType := http.DetectContentType(raw)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	// размеры из заголовка проверяются до декодирования
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxPixels/cfg.Height {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	bounds := img.Bounds()
+	p := &Processed{
+		Original:    raw,
+		ContentType: contentType,
+		Extension:   ext,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Thumbnails:  map[string][]byte{},
+	}
+
+	for _, size := range ThumbnailSizes {
+		thumb := fit(img, size.Max)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 82}); err != nil {
+			return nil, err
+		}
+		p.Thumbnails[size.Name] = buf.Bytes()
+	}
+
+	return p, nil
+}
+
+// fit уменьшает изображение, вписывая его в квадрат max×max; маленькие не увеличивает
+func fit(img image.Image, max int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w > max || h > max {
+		if w >= h {
+			h = h * max / w
+			w = max
+		} else {
+			w = w * max / h
+			h = max
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, max1(w), max1(h)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func max1(v int) int {
+	if v < 1 {
+		return 1
+	}
+	return v
+}
+
+func OriginalKey(apartmentID, photoID, ext string) string {
+	return "apartments/" + apartmentID + "/" + photoID + "/original." + ext
+}
+
+func ThumbnailKey(apartmentID, photoID, size string) string {
+	return "apartments/" + apartmentID + "/" + photoID + "/" + size + ".jpg"
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"booking_service/internal/models"
+	"booking_service/internal/safehttp"
+	servererrors "booking_service/internal/server_errors"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// authorize пропускает владельца и соавторов с нужным правом
+func authorize(db *gorm.DB, ap *models.Apartment, userID, permission string) error {
+	if ap.OwnerID == userID {
+		return nil
+	}
+
+	if permission != models.PermOwner {
+		var ch models.CoHost
+		err := db.Where("ap_id = ? AND user_id = ?", ap.ID, userID).First(&ch).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && ch.Allows(permission) {
+			return nil
+		}
+	}
+
+	return &servererrors.ForbiddenAccessError{
+		UserId:       userID,
+		ResourceType: "apartment",
+		ResourceId:   ap.ID,
+	}
+}
+
+// lockApartmentFor блокирует строку апартамента до конца транзакции и проверяет право пользователя
+func lockApartmentFor(tx *gorm.DB, apID, userID, permission string) (models.Apartment, error) {
+	var ap models.Apartment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", apID).First(&ap).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Apartment{}, &servererrors.NotFoundError{Entity: "apartment", Key: apID}
+		}
+		return models.Apartment{}, err
+	}
+
+	if err := authorize(tx, &ap, userID, permission); err != nil {
+		return models.Apartment{}, err
+	}
+
+	return ap, nil
+}
+
+func (r *repositoryWithTM) CheckApartmentAccess(apID, userID, permission string) error {
+	var ap models.Apartment
+	if err := r.tm.db.Select("id", "owner_id").Where("id = ?", apID).First(&ap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &servererrors.NotFoundError{Entity: "apartment", Key: apID}
+		}
+		return err
+	}
+
+	return authorize(r.tm.db, &ap, userID, permission)
+}
+
+// checkPublicURL не даёт направить запросы сервиса по ссылке пользователя во внутреннюю сеть
+func checkPublicURL(raw string) error {
+	if err := safehttp.CheckURL(context.Background(), raw); err != nil {
+		ve := &servererrors.ValidationError{}
+		ve.Add("url", err.Error())
+		return ve
+	}
+	return nil
+}
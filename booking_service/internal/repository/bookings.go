@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeBookings — даты занимают подтверждённые брони и те, что ждут оплаты
+func activeBookings(db *gorm.DB) *gorm.DB {
+	return db.Where("bookings.status IN ?", []string{models.BookingConfirmed, models.BookingPendingPayment})
+}
+
+// CancelBooking отменяет бронь до заезда. Отменить может гость или тот, кто управляет
+// бронями апартамента (владелец, соавтор с manage_bookings)
+func (r *repositoryWithTM) CancelBooking(id string, dto *dtos.UserActionDTO) (models.Booking, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Booking{}, err
+	}
+
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Joins("Apartment").
+		Where("bookings.booking_id = ?", id).
+		First(&booking).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Booking{}, &servererrors.NotFoundError{Entity: "booking", Key: id}
+		}
+		return models.Booking{}, err
+	}
+
+	if booking.UserID != dto.UserID {
+		if err := authorize(tx, &booking.Apartment, dto.UserID, models.PermManageBookings); err != nil {
+			_ = r.tm.rollback(tx)
+			return models.Booking{}, err
+		}
+	}
+
+	if booking.Status == models.BookingCancelled {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, &servererrors.StateConflictError{Entity: "booking", Key: id, Reason: "booking is already cancelled"}
+	}
+	if booking.Status == models.BookingPaymentFailed {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, &servererrors.StateConflictError{Entity: "booking", Key: id, Reason: "booking was not paid"}
+	}
+
+	now := time.Now()
+	if !booking.TimeFrom.After(now) {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, &servererrors.StateConflictError{Entity: "booking", Key: id, Reason: "stay has already started"}
+	}
+
+	booking.Status = models.BookingCancelled
+	booking.CancelledAt = &now
+	booking.CancelledBy = &dto.UserID
+	if err := tx.Model(&booking).Select("status", "cancelled_at", "cancelled_by").Updates(&booking).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+
+	payload := bookingPayload(&booking, booking.Apartment.OwnerID)
+	payload.CancelledBy = dto.UserID
+	if err := r.tm.emit(tx, events.BookingCancelled, booking.ID, payload); err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.Booking{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Booking %s cancelled by %s", id, dto.UserID)
+	return booking, nil
+}
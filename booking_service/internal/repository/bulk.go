@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rowError — ошибка, которая относится к строке импорта, а не ко всему запросу
+func rowError(err error) bool {
+	var ve *servererrors.ValidationError
+	var aee *servererrors.AlreadyExistsError
+	return errors.As(err, &ve) || errors.As(err, &aee)
+}
+
+// ImportApartments проверяет каждую строку по правилам AddApartment, включая повтор адреса
+// внутри файла. Атомарный режим создаёт все строки одной транзакцией и только если ошибок нет,
+// частичный — каждую верную строку отдельно, и ошибка записи остаётся ошибкой этой строки.
+// При dryRun ничего не записывается
+func (r *repositoryWithTM) ImportApartments(rows []dtos.ApartmentImportRow, partial, dryRun bool) error {
+	drafts := make([]*apartmentDraft, len(rows))
+	seen := map[string]bool{}
+	failed := 0
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Err != nil {
+			failed++
+			continue
+		}
+
+		if seen[row.DTO.Address] {
+			row.Err = &servererrors.AlreadyExistsError{Field: "address", Value: row.DTO.Address}
+			failed++
+			continue
+		}
+
+		draft, err := prepareApartment(r.tm.db, &row.DTO)
+		if err != nil {
+			if !rowError(err) {
+				return err
+			}
+			row.Err = err
+			failed++
+			continue
+		}
+
+		seen[row.DTO.Address] = true
+		drafts[i] = &draft
+	}
+
+	if dryRun || (!partial && failed > 0) {
+		return nil
+	}
+
+	if partial {
+		for i, draft := range drafts {
+			if draft == nil {
+				continue
+			}
+			// строка, которую не удалось записать, не останавливает остальные
+			if _, err := r.createDrafts([]*apartmentDraft{draft}); err != nil {
+				if !rowError(err) {
+					logrus.WithError(err).Warnf("Import of line %d failed", rows[i].Line)
+					err = errors.New("apartment could not be saved")
+				}
+				rows[i].Err = err
+				failed++
+				continue
+			}
+			rows[i].ApartmentID = draft.ap.ID
+		}
+	} else {
+		if at, err := r.createDrafts(drafts); err != nil {
+			// адрес заняли между проверкой и записью: отмечаем строку, остальные не созданы
+			if at < 0 || !rowError(err) {
+				return err
+			}
+			rows[at].Err = err
+			return nil
+		}
+		for i, draft := range drafts {
+			rows[i].ApartmentID = draft.ap.ID
+		}
+	}
+
+	logrus.WithTime(time.Now()).Infof("Imported %d of %d apartments", len(rows)-failed, len(rows))
+	return nil
+}
+
+// createDrafts создаёт апартаменты одной транзакцией; nil пропускаются.
+// При ошибке возвращает индекс черновика, на котором она случилась, или -1
+func (r *repositoryWithTM) createDrafts(drafts []*apartmentDraft) (int, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return -1, err
+	}
+
+	for i, draft := range drafts {
+		if draft == nil {
+			continue
+		}
+		if err := r.createApartment(tx, draft); err != nil {
+			_ = r.tm.rollback(tx)
+			return i, err
+		}
+	}
+
+	// COMMIT (TRANSACTION END)
+	return -1, r.tm.commit(tx)
+}
+
+// ExportApartments — портфель владельца с текущими описаниями; без статуса — все, кроме архивных
+func (r *repositoryWithTM) ExportApartments(ownerID, status string) ([]models.Apartment, error) {
+	db := r.tm.db.
+		Preload("Descriptions", descriptionsAt(nil)).
+		Where("owner_id = ?", ownerID)
+	if status == "" {
+		db = db.Where("status <> ?", models.StatusArchived)
+	} else {
+		db = db.Where("status = ?", status)
+	}
+
+	var apartments []models.Apartment
+	if err := db.Order("address").Find(&apartments).Error; err != nil {
+		return nil, err
+	}
+	return apartments, nil
+}
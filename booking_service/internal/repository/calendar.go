@@ -0,0 +1,451 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxSyncError — столько символов ошибки синхронизации сохраняется в импорте
+const maxSyncError = 500
+
+func newCalendarToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GetCalendarFeed возвращает секретную ленту апартамента, создавая её при первом обращении
+func (r *repositoryWithTM) GetCalendarFeed(apID, userID string) (models.CalendarFeed, error) {
+	if err := r.CheckApartmentAccess(apID, userID, models.PermManageBookings); err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	feed := models.CalendarFeed{ApartmentID: apID, Token: token, CreatedAt: time.Now()}
+	if err := r.tm.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&feed).Error; err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	// при гонке двух первых запросов побеждает одна запись — возвращаем её
+	if err := r.tm.db.Where("ap_id = ?", apID).First(&feed).Error; err != nil {
+		return models.CalendarFeed{}, err
+	}
+	return feed, nil
+}
+
+// RotateCalendarFeed выдаёт новый токен; старая ссылка сразу перестаёт работать
+func (r *repositoryWithTM) RotateCalendarFeed(apID, userID string) (models.CalendarFeed, error) {
+	if err := r.CheckApartmentAccess(apID, userID, models.PermManageBookings); err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return models.CalendarFeed{}, err
+	}
+	feed := models.CalendarFeed{ApartmentID: apID, Token: token, CreatedAt: time.Now()}
+	// created_at с default:now() UpdateAll не обновляет
+	if err := r.tm.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ap_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "created_at"}),
+	}).Create(&feed).Error; err != nil {
+		return models.CalendarFeed{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Calendar feed of apartment %s rotated by %s", apID, userID)
+	return feed, nil
+}
+
+func (r *repositoryWithTM) GetCalendarByToken(token string) (models.Apartment, []models.Booking, []models.CalendarBlock, error) {
+	var feed models.CalendarFeed
+	if err := r.tm.db.Where("token = ?", token).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// сам токен в ошибку не попадает: это секрет
+			return models.Apartment{}, nil, nil, &servererrors.NotFoundError{Entity: "calendar", Key: "feed"}
+		}
+		return models.Apartment{}, nil, nil, err
+	}
+
+	var ap models.Apartment
+	if err := r.tm.db.Where("id = ?", feed.ApartmentID).First(&ap).Error; err != nil {
+		return models.Apartment{}, nil, nil, err
+	}
+
+	var bookings []models.Booking
+	if err := r.tm.db.
+		Where("ap_id = ?", ap.ID).
+		Where("time_to > now()").
+		Scopes(activeBookings).
+		Order("time_from").
+		Find(&bookings).Error; err != nil {
+		return models.Apartment{}, nil, nil, err
+	}
+
+	var blocks []models.CalendarBlock
+	if err := r.tm.db.
+		Where("ap_id = ?", ap.ID).
+		Where("time_to > now()").
+		Order("time_from").
+		Find(&blocks).Error; err != nil {
+		return models.Apartment{}, nil, nil, err
+	}
+
+	return ap, bookings, blocks, nil
+}
+
+func (r *repositoryWithTM) AddCalendarImport(apID string, dto *dtos.CalendarImportDTO) (models.CalendarImport, error) {
+	if err := checkPublicURL(dto.URL); err != nil {
+		return models.CalendarImport{}, err
+	}
+
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.CalendarImport{}, err
+	}
+
+	ap, err := lockApartmentFor(tx, apID, dto.OwnerID, models.PermManageBookings)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.CalendarImport{}, err
+	}
+	if ap.Status == models.StatusArchived {
+		_ = r.tm.rollback(tx)
+		return models.CalendarImport{}, &servererrors.StateConflictError{Entity: "apartment", Key: apID, Reason: "archived apartment cannot be updated"}
+	}
+
+	var count int64
+	if err := tx.Model(&models.CalendarImport{}).Where("ap_id = ? AND url = ?", apID, dto.URL).Count(&count).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.CalendarImport{}, err
+	}
+	if count > 0 {
+		_ = r.tm.rollback(tx)
+		return models.CalendarImport{}, &servererrors.AlreadyExistsError{Field: "url", Value: dto.URL}
+	}
+
+	// last_synced_at пуст — импорт подхватится при ближайшем проходе синхронизации
+	imp := models.CalendarImport{
+		ID:          uuid.New().String(),
+		ApartmentID: apID,
+		URL:         dto.URL,
+		Name:        dto.Name,
+		CreatedBy:   dto.OwnerID,
+		CreatedAt:   time.Now(),
+	}
+	if err := tx.Omit("Apartment").Create(&imp).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.CalendarImport{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.CalendarImport{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Calendar import %s added to apartment %s", imp.ID, apID)
+	return imp, nil
+}
+
+func (r *repositoryWithTM) GetCalendarImports(apID, userID string) ([]models.CalendarImport, error) {
+	if err := r.CheckApartmentAccess(apID, userID, models.PermManageBookings); err != nil {
+		return nil, err
+	}
+
+	var imports []models.CalendarImport
+	if err := r.tm.db.Where("ap_id = ?", apID).Order("created_at").Find(&imports).Error; err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// DeleteCalendarImport удаляет импорт вместе с его блоками и конфликтами
+func (r *repositoryWithTM) DeleteCalendarImport(apID, importID, userID string) error {
+	if err := r.CheckApartmentAccess(apID, userID, models.PermManageBookings); err != nil {
+		return err
+	}
+
+	res := r.tm.db.Where("import_id = ? AND ap_id = ?", importID, apID).Delete(&models.CalendarImport{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &servererrors.NotFoundError{Entity: "calendar import", Key: importID}
+	}
+	return nil
+}
+
+// GetCalendarConflicts — нерешённые конфликты: бронь ещё активна и не закончилась
+func (r *repositoryWithTM) GetCalendarConflicts(apID, userID string) ([]models.CalendarConflict, error) {
+	if err := r.CheckApartmentAccess(apID, userID, models.PermManageBookings); err != nil {
+		return nil, err
+	}
+
+	var conflicts []models.CalendarConflict
+	if err := r.tm.db.
+		Joins("Block").
+		Joins("Booking").
+		Where("calendar_conflicts.ap_id = ?", apID).
+		Where(`"Booking".status IN ? AND "Booking".time_to > now()`, []string{models.BookingConfirmed, models.BookingPendingPayment}).
+		Order(`"Booking".time_from`).
+		Find(&conflicts).Error; err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// GetCalendarImportsToSync — импорты неархивных апартаментов, не синхронизированные с syncedBefore;
+// новые импорты идут первыми
+func (r *repositoryWithTM) GetCalendarImportsToSync(syncedBefore time.Time, limit int) ([]models.CalendarImport, error) {
+	var imports []models.CalendarImport
+	if err := r.tm.db.
+		Joins("Apartment").
+		Where(`"Apartment".status <> ?`, models.StatusArchived).
+		Where("calendar_imports.last_synced_at IS NULL OR calendar_imports.last_synced_at < ?", syncedBefore).
+		Order("calendar_imports.last_synced_at NULLS FIRST").
+		Limit(limit).
+		Find(&imports).Error; err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// RecordCalendarSyncError откладывает следующую попытку на полный интервал; блоки не трогаются
+func (r *repositoryWithTM) RecordCalendarSyncError(importID, reason string) error {
+	if len(reason) > maxSyncError {
+		reason = reason[:maxSyncError]
+	}
+	return r.tm.db.Model(&models.CalendarImport{}).
+		Where("import_id = ?", importID).
+		Updates(map[string]any{"last_synced_at": time.Now(), "last_error": reason}).Error
+}
+
+// calendarBlocks переводит события внешнего календаря в блоки. Даты событий на весь день —
+// ночи, как у брони: блок занимает время от заезда первого дня до выезда последнего по
+// правилам апартамента. Пустые и уже закончившиеся события пропускаются
+func calendarBlocks(ap *models.Apartment, rules *models.StayRules, imp *models.CalendarImport, evs []models.CalendarEvent, now time.Time) []models.CalendarBlock {
+	blocks := []models.CalendarBlock{}
+	seen := map[string]bool{}
+	for _, e := range evs {
+		from, to := e.Start, e.End
+		if e.AllDay {
+			var err error
+			if from, to, err = localStay(ap, rules, e.Start.Format(time.DateOnly), e.End.Format(time.DateOnly)); err != nil {
+				continue
+			}
+		}
+		if !to.After(from) || !to.After(now) {
+			continue
+		}
+
+		// некоторые площадки повторяют UID у нескольких событий
+		uid := e.UID
+		if seen[uid] {
+			uid += "#" + from.UTC().Format(time.RFC3339)
+		}
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		blocks = append(blocks, models.CalendarBlock{
+			ImportID:    imp.ID,
+			ApartmentID: ap.ID,
+			UID:         uid,
+			Summary:     e.Summary,
+			TimeFrom:    from.UTC(),
+			TimeTo:      to.UTC(),
+		})
+	}
+	return blocks
+}
+
+func (r *repositoryWithTM) ReplaceCalendarBlocks(importID string, evs []models.CalendarEvent) ([]models.CalendarConflict, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var imp models.CalendarImport
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("import_id = ?", importID).First(&imp).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &servererrors.NotFoundError{Entity: "calendar import", Key: importID}
+		}
+		return nil, err
+	}
+
+	// FOR UPDATE против FOR SHARE в CreateBooking: бронь не проскочит между заменой блоков
+	// и поиском конфликтов, а после коммита уже увидит новые блоки
+	var ap models.Apartment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", imp.ApartmentID).First(&ap).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	rules, err := stayRulesFor(tx, ap.ID)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	now := time.Now()
+	blocks := calendarBlocks(&ap, &rules, &imp, evs, now)
+
+	var existing []models.CalendarBlock
+	if err := tx.Where("import_id = ?", imp.ID).Find(&existing).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+	idByUID := map[string]string{}
+	for _, b := range existing {
+		idByUID[b.UID] = b.ID
+	}
+
+	// блоки с прежним UID сохраняют id, чтобы не терять уже найденные по ним конфликты
+	kept := map[string]bool{}
+	for i := range blocks {
+		if id, ok := idByUID[blocks[i].UID]; ok {
+			blocks[i].ID = id
+			kept[id] = true
+		} else {
+			blocks[i].ID = uuid.New().String()
+		}
+	}
+	stale := []string{}
+	for _, b := range existing {
+		if !kept[b.ID] {
+			stale = append(stale, b.ID)
+		}
+	}
+
+	if len(stale) > 0 {
+		if err := tx.Where("block_id IN ?", stale).Delete(&models.CalendarBlock{}).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return nil, err
+		}
+	}
+	if len(blocks) > 0 {
+		if err := tx.Omit("Import").Clauses(clause.OnConflict{UpdateAll: true}).Create(&blocks).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return nil, err
+		}
+	}
+
+	created, err := r.syncCalendarConflicts(tx, &ap, &imp, now)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	if err := tx.Model(&imp).Updates(map[string]any{
+		"last_synced_at": now,
+		"last_error":     "",
+		"event_count":    len(blocks),
+	}).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// syncCalendarConflicts приводит конфликты импорта к текущим пересечениям блоков с активными
+// бронями. О новых конфликтах сообщается событием calendar.conflict; их же и возвращает
+func (r *repositoryWithTM) syncCalendarConflicts(tx *gorm.DB, ap *models.Apartment, imp *models.CalendarImport, now time.Time) ([]models.CalendarConflict, error) {
+	var overlaps []struct {
+		BlockID   string
+		BookingID string
+	}
+	if err := tx.Table("calendar_blocks").
+		Select("calendar_blocks.block_id, bookings.booking_id").
+		Joins("JOIN bookings ON bookings.ap_id = calendar_blocks.ap_id AND bookings.time_from < calendar_blocks.time_to AND bookings.time_to > calendar_blocks.time_from").
+		Where("calendar_blocks.import_id = ?", imp.ID).
+		Scopes(activeBookings).
+		Scan(&overlaps).Error; err != nil {
+		return nil, err
+	}
+
+	var existing []models.CalendarConflict
+	if err := tx.Joins("Block").Where(`"Block".import_id = ?`, imp.ID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	current := map[string]bool{}
+	for _, o := range overlaps {
+		current[o.BlockID+"/"+o.BookingID] = true
+	}
+	known := map[string]bool{}
+	resolved := []string{}
+	for _, c := range existing {
+		key := c.BlockID + "/" + c.BookingID
+		if current[key] {
+			known[key] = true
+		} else {
+			resolved = append(resolved, c.ID)
+		}
+	}
+	if len(resolved) > 0 {
+		if err := tx.Where("conflict_id IN ?", resolved).Delete(&models.CalendarConflict{}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	created := []models.CalendarConflict{}
+	for _, o := range overlaps {
+		if known[o.BlockID+"/"+o.BookingID] {
+			continue
+		}
+		c := models.CalendarConflict{
+			ID:          uuid.New().String(),
+			ApartmentID: ap.ID,
+			BlockID:     o.BlockID,
+			BookingID:   o.BookingID,
+			DetectedAt:  now,
+		}
+		if err := tx.Omit("Block", "Booking").Create(&c).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Where("block_id = ?", c.BlockID).First(&c.Block).Error; err != nil {
+			return nil, err
+		}
+
+		if err := r.tm.emit(tx, events.CalendarConflict, c.ID, events.CalendarConflictPayload{
+			ID:          c.ID,
+			ApartmentID: ap.ID,
+			OwnerID:     ap.OwnerID,
+			BookingID:   c.BookingID,
+			ImportID:    imp.ID,
+			Summary:     c.Block.Summary,
+			TimeFrom:    c.Block.TimeFrom,
+			TimeTo:      c.Block.TimeTo,
+		}); err != nil {
+			return nil, err
+		}
+		created = append(created, c)
+	}
+
+	return created, nil
+}
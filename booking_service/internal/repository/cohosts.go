@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// SetCoHost создаёт соавтора или заменяет его права целиком; управляет соавторами только владелец
+func (r *repositoryWithTM) SetCoHost(apID, userID string, dto *dtos.CoHostDTO) (models.CoHost, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.CoHost{}, err
+	}
+
+	ap, err := lockApartmentFor(tx, apID, dto.OwnerID, models.PermOwner)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.CoHost{}, err
+	}
+
+	if ap.OwnerID == userID {
+		_ = r.tm.rollback(tx)
+		return models.CoHost{}, &servererrors.BadRequestError{Violation: "owner cannot be a co-host"}
+	}
+
+	ch := models.CoHost{
+		ApartmentID: apID,
+		UserID:      userID,
+		GrantedBy:   dto.OwnerID,
+		CreatedAt:   time.Now(),
+	}
+	for _, p := range dto.Permissions {
+		switch p {
+		case models.PermEditPricing:
+			ch.CanEditPricing = true
+		case models.PermEditListing:
+			ch.CanEditListing = true
+		case models.PermManageBookings:
+			ch.CanManageBookings = true
+		}
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ap_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"can_edit_pricing", "can_edit_listing", "can_manage_bookings", "granted_by"}),
+	}).Create(&ch).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.CoHost{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.CoHost{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Co-host %s of apartment %s granted %v", userID, apID, ch.Permissions())
+	return ch, nil
+}
+
+func (r *repositoryWithTM) RemoveCoHost(apID, userID, ownerID string) error {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := lockApartmentFor(tx, apID, ownerID, models.PermOwner); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	res := tx.Where("ap_id = ? AND user_id = ?", apID, userID).Delete(&models.CoHost{})
+	if res.Error != nil {
+		_ = r.tm.rollback(tx)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		_ = r.tm.rollback(tx)
+		return &servererrors.NotFoundError{Entity: "co-host", Key: userID}
+	}
+
+	// COMMIT (TRANSACTION END)
+	return r.tm.commit(tx)
+}
+
+func (r *repositoryWithTM) GetCoHosts(apID, ownerID string) ([]models.CoHost, error) {
+	if err := r.CheckApartmentAccess(apID, ownerID, models.PermOwner); err != nil {
+		return nil, err
+	}
+
+	var cohosts []models.CoHost
+	if err := r.tm.db.Where("ap_id = ?", apID).Order("created_at").Find(&cohosts).Error; err != nil {
+		return nil, err
+	}
+	return cohosts, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"booking_service/internal/amenities"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsedDescription — проверенное содержимое info и amenities для новой версии описания
+type parsedDescription struct {
+	rooms     *int
+	beds      *int
+	other     map[string]string
+	amenities map[string]any
+}
+
+// parseDescription разбирает info (rooms и beds отдельно, ключи каталога — как типизированные
+// удобства, остальное — свободный текст) и amenities. base — удобства, поверх которых
+// накладываются значения из info, если amenities не переданы.
+func parseDescription(info map[string]string, am map[string]any, base map[string]any) (parsedDescription, error) {
+	ve := &servererrors.ValidationError{}
+	p := parsedDescription{
+		other:     map[string]string{},
+		amenities: map[string]any{},
+	}
+
+	if am == nil {
+		for k, v := range base {
+			p.amenities[k] = v
+		}
+	}
+
+	for k, v := range am {
+		def, ok := amenities.Lookup(k)
+		if !ok {
+			ve.Add("amenities."+k, "unknown amenity")
+			continue
+		}
+		val, err := def.Parse(v)
+		if err != nil {
+			ve.Add("amenities."+k, err.Error())
+			continue
+		}
+		p.amenities[k] = val
+	}
+
+	for k, v := range info {
+		switch k {
+		case "rooms":
+			val, err := strconv.Atoi(v)
+			if err != nil {
+				ve.Add("info.rooms", "must be an integer")
+			} else if val <= 0 {
+				ve.Add("info.rooms", "must be > 0")
+			} else {
+				p.rooms = &val
+			}
+		case "beds":
+			val, err := strconv.Atoi(v)
+			if err != nil {
+				ve.Add("info.beds", "must be an integer")
+			} else if val < 0 {
+				ve.Add("info.beds", "must be >= 0")
+			} else {
+				p.beds = &val
+			}
+		default:
+			def, ok := amenities.Lookup(k)
+			if !ok {
+				p.other[k] = v
+				continue
+			}
+			val, err := def.Parse(v)
+			if err != nil {
+				ve.Add("info."+k, err.Error())
+				continue
+			}
+			p.amenities[k] = val
+		}
+	}
+
+	if len(ve.Fields) > 0 {
+		return parsedDescription{}, ve
+	}
+
+	return p, nil
+}
+
+func (p parsedDescription) apply(desc *models.Description) {
+	descJSON, _ := json.Marshal(p.other)
+	amenitiesJSON, _ := json.Marshal(p.amenities)
+
+	desc.Description = string(descJSON)
+	desc.Amenities = string(amenitiesJSON)
+	if p.rooms != nil {
+		desc.Rooms = *p.rooms
+	}
+	if p.beds != nil {
+		desc.Beds = *p.beds
+	}
+}
+
+// parseAmenityFilter разбирает "wifi,parking,view:sea": ключ без значения означает true
+// и допустим только для булевых удобств. Результат — JSON для оператора @>.
+func parseAmenityFilter(raw string) (string, error) {
+	required := map[string]any{}
+
+	for _, token := range strings.Split(raw, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(token, ":")
+		def, ok := amenities.Lookup(key)
+		if !ok {
+			return "", &servererrors.BadRequestError{Violation: fmt.Sprintf("unknown amenity '%s'", key)}
+		}
+
+		if !hasValue {
+			if def.Kind != amenities.KindBool {
+				return "", &servererrors.BadRequestError{Violation: fmt.Sprintf("amenity '%s' needs a value", key)}
+			}
+			required[key] = true
+			continue
+		}
+
+		val, err := def.Parse(value)
+		if err != nil {
+			return "", &servererrors.BadRequestError{Violation: fmt.Sprintf("amenity '%s' %v", key, err)}
+		}
+		required[key] = val
+	}
+
+	out, _ := json.Marshal(required)
+	return string(out), nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+)
+
+func apartmentPayload(ap *models.Apartment) events.ApartmentPayload {
+	return events.ApartmentPayload{
+		ID:       ap.ID,
+		OwnerID:  ap.OwnerID,
+		Status:   ap.Status,
+		Price:    ap.Price,
+		Currency: ap.Currency,
+		City:     ap.City,
+	}
+}
+
+func bookingPayload(b *models.Booking, ownerID string) events.BookingPayload {
+	return events.BookingPayload{
+		ID:          b.ID,
+		ApartmentID: b.ApartmentID,
+		OwnerID:     ownerID,
+		UserID:      b.UserID,
+		TimeFrom:    b.TimeFrom,
+		TimeTo:      b.TimeTo,
+		Guests:      b.Guests.Count(),
+	}
+}
+
+// GetOwnerEventsSince догоняет поток по номеру публикации. Номера выдаются в порядке
+// фиксации, поэтому событие с меньшим номером не появится после уже прочитанного
+func (r *repositoryWithTM) GetOwnerEventsSince(ownerID string, after int64, limit int) ([]models.OutboxEvent, error) {
+	var evs []models.OutboxEvent
+	if err := r.tm.db.
+		Where("publish_seq > ?", after).
+		Where("payload->>'owner_id' = ? OR payload->>'from_owner_id' = ? OR payload->>'to_owner_id' = ?", ownerID, ownerID, ownerID).
+		Order("publish_seq").
+		Limit(limit).
+		Find(&evs).Error; err != nil {
+		return nil, err
+	}
+	return evs, nil
+}
+
+// GetLatestPublishSeq — последний выданный номер публикации; с него начинается новый поток
+func (r *repositoryWithTM) GetLatestPublishSeq() (int64, error) {
+	var seq int64
+	if err := r.tm.db.Model(&models.OutboxEvent{}).Select("COALESCE(max(publish_seq), 0)").Scan(&seq).Error; err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	servererrors "booking_service/internal/server_errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const earthRadiusKm = 6371.0
+
+// applyGeoFilter добавляет к запросу поиск по радиусу (lat, lng, radius_km)
+// или по прямоугольнику (bbox=minLat,minLng,maxLat,maxLng)
+func applyGeoFilter(db *gorm.DB, filter map[string]string) (*gorm.DB, error) {
+	if bbox, ok := filter["bbox"]; ok {
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return nil, &servererrors.BadRequestError{Violation: "bbox must be minLat,minLng,maxLat,maxLng"}
+		}
+
+		coords := make([]float64, 4)
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return nil, &servererrors.BadRequestError{Violation: "bbox must be minLat,minLng,maxLat,maxLng"}
+			}
+			coords[i] = v
+		}
+
+		for i, v := range coords {
+			limit := 90.0
+			if i%2 == 1 {
+				limit = 180
+			}
+			if v < -limit || v > limit {
+				return nil, &servererrors.BadRequestError{Violation: "bbox latitudes must be within ±90 and longitudes within ±180"}
+			}
+		}
+
+		if coords[0] > coords[2] || coords[1] > coords[3] {
+			return nil, &servererrors.BadRequestError{Violation: "bbox min corner must be below max corner"}
+		}
+
+		db = db.Where("apartments.lat BETWEEN ? AND ? AND apartments.lng BETWEEN ? AND ?",
+			coords[0], coords[2], coords[1], coords[3])
+	}
+
+	latRaw, hasLat := filter["lat"]
+	lngRaw, hasLng := filter["lng"]
+	radiusRaw, hasRadius := filter["radius_km"]
+
+	if !hasLat && !hasLng && !hasRadius {
+		return db, nil
+	}
+
+	if !hasLat || !hasLng || !hasRadius {
+		return nil, &servererrors.BadRequestError{Violation: "lat, lng and radius_km must be given together"}
+	}
+
+	lat, errLat := strconv.ParseFloat(latRaw, 64)
+	lng, errLng := strconv.ParseFloat(lngRaw, 64)
+	radius, errRadius := strconv.ParseFloat(radiusRaw, 64)
+	if errLat != nil || errLng != nil || errRadius != nil ||
+		lat < -90 || lat > 90 || lng < -180 || lng > 180 || radius <= 0 {
+		return nil, &servererrors.BadRequestError{Violation: "lat, lng and radius_km must be valid coordinates and a positive distance"}
+	}
+
+	// грубый прямоугольник отсекает строки по индексу, точное расстояние — формула гаверсинуса
+	dLat := radius / earthRadiusKm * 180 / math.Pi
+	dLng := dLat / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+
+	db = db.
+		Where("apartments.lat BETWEEN ? AND ? AND apartments.lng BETWEEN ? AND ?",
+			lat-dLat, lat+dLat, lng-dLng, lng+dLng).
+		Where(`2 * ? * asin(sqrt(
+			power(sin(radians(apartments.lat - ?) / 2), 2) +
+			cos(radians(?)) * cos(radians(apartments.lat)) * power(sin(radians(apartments.lng - ?) / 2), 2)
+		)) <= ?`, earthRadiusKm, lat, lat, lng, radius)
+
+	return db, nil
+}
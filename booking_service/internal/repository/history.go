@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetApartmentHistory загружает прошлые цены (apartments_scd4) и все версии описания,
+// чьи периоды действия пересекаются с [from, to]; границы необязательны
+func (r *repositoryWithTM) GetApartmentHistory(id string, from, to *time.Time) (models.Apartment, error) {
+	var ap models.Apartment
+
+	err := r.tm.db.
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			if from != nil {
+				db = db.Where("invalid_since > ?", *from)
+			}
+			if to != nil {
+				db = db.Where("updated_at <= ?", *to)
+			}
+			return db.Order("updated_at")
+		}).
+		Preload("Descriptions", func(db *gorm.DB) *gorm.DB {
+			if from != nil {
+				db = db.Where("valid_to > ?", *from)
+			}
+			if to != nil {
+				db = db.Where("valid_from <= ?", *to)
+			}
+			return db.Order("valid_from")
+		}).
+		Where("id = ?", id).
+		First(&ap).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Apartment{}, &servererrors.NotFoundError{Entity: "apartment", Key: id}
+		}
+		return models.Apartment{}, err
+	}
+
+	// как и карточка: черновики и архивные объявления снаружи не видны
+	if !ap.IsVisible() {
+		return models.Apartment{}, &servererrors.NotFoundError{Entity: "apartment", Key: id}
+	}
+
+	return ap, nil
+}
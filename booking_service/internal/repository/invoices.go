@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/money"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invoicePrefix — номер счёта: INV-<год>-<порядковый номер в году>
+const invoicePrefix = "INV"
+
+func (r *repositoryWithTM) GetBillingProfile(userID string) (models.BillingProfile, error) {
+	profile := models.BillingProfile{UserID: userID}
+	err := r.tm.db.Where("user_id = ?", userID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.BillingProfile{}, err
+	}
+	return profile, nil
+}
+
+func (r *repositoryWithTM) UpdateBillingProfile(userID string, dto *dtos.BillingProfileDTO) (models.BillingProfile, error) {
+	profile := models.BillingProfile{
+		UserID: userID,
+		BillingDetails: models.BillingDetails{
+			Name:    dto.Name,
+			Company: dto.Company,
+			TaxID:   dto.TaxID,
+			Address: dto.Address,
+		},
+		UpdatedAt: time.Now(),
+	}
+
+	if err := r.tm.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "company", "tax_id", "address", "updated_at"}),
+	}).Create(&profile).Error; err != nil {
+		return models.BillingProfile{}, err
+	}
+
+	return profile, nil
+}
+
+// nextInvoiceNumber выдаёт следующий номер серии. Счётчик увеличивается в транзакции счёта:
+// строка счётчика заблокирована до её конца, а при откате номер не пропадает
+func nextInvoiceNumber(tx *gorm.DB, series string) (int64, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InvoiceCounter{Series: series}).Error; err != nil {
+		return 0, err
+	}
+
+	var seq int64
+	if err := tx.Raw("UPDATE invoice_counters SET last = last + 1 WHERE series = ? RETURNING last", series).
+		Scan(&seq).Error; err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+func billingDetails(tx *gorm.DB, userID string) (models.BillingDetails, error) {
+	var profile models.BillingProfile
+	err := tx.Where("user_id = ?", userID).First(&profile).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.BillingDetails{}, err
+	}
+	return profile.BillingDetails, nil
+}
+
+// invoiceLines — строки счёта по строкам стоимости брони; сумма строк равна оплаченной сумме.
+// Брони, созданные до появления строк стоимости, выставляются одной строкой проживания
+func invoiceLines(b *models.Booking, payment *models.Payment) []models.InvoiceLine {
+	if len(b.LineItems) == 0 {
+		total := money.FromMinor(payment.Amount, payment.Currency)
+		loc := b.Apartment.Location()
+		nights := stayNights(b.TimeFrom, b.TimeTo, loc)
+		return []models.InvoiceLine{{
+			Position:    1,
+			Kind:        models.LineAccommodation,
+			Description: fmt.Sprintf("Accommodation at %s, %s - %s", b.Apartment.Address, b.TimeFrom.In(loc).Format(time.DateOnly), b.TimeTo.In(loc).Format(time.DateOnly)),
+			Quantity:    nights,
+			UnitPrice:   money.Round(total.Div(decimal.NewFromInt(int64(nights))), payment.Currency),
+			Amount:      total,
+			TaxRate:     decimal.Zero,
+			TaxAmount:   decimal.Zero,
+		}}
+	}
+
+	lines := make([]models.InvoiceLine, 0, len(b.LineItems))
+	for _, item := range b.LineItems {
+		lines = append(lines, models.InvoiceLine{
+			Position:    item.Position,
+			Kind:        item.Kind,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitAmount,
+			Amount:      item.Amount,
+			TaxRate:     decimal.Zero,
+			TaxAmount:   decimal.Zero,
+		})
+	}
+	return lines
+}
+
+// GetInvoice возвращает счёт по брони, при первом запросе выставляя его.
+// Счёт доступен гостю и тем, кто управляет бронями апартамента
+func (r *repositoryWithTM) GetInvoice(bookingID, userID string) (models.Invoice, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	// блокировка брони: параллельные запросы не выставят второй счёт
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Joins("Apartment").
+		Where("bookings.booking_id = ?", bookingID).
+		First(&booking).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Invoice{}, &servererrors.NotFoundError{Entity: "booking", Key: bookingID}
+		}
+		return models.Invoice{}, err
+	}
+
+	if booking.UserID != userID {
+		if err := authorize(tx, &booking.Apartment, userID, models.PermManageBookings); err != nil {
+			_ = r.tm.rollback(tx)
+			return models.Invoice{}, err
+		}
+	}
+
+	if err := tx.Where("booking_id = ?", bookingID).Order("position").Find(&booking.LineItems).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+
+	var invoice models.Invoice
+	err = tx.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("booking_id = ?", bookingID).
+		First(&invoice).Error
+	if err == nil {
+		_ = r.tm.rollback(tx)
+		return invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+
+	var payment models.Payment
+	if err := tx.Where("booking_id = ?", bookingID).First(&payment).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+	if payment.Status != models.PaymentCaptured {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, &servererrors.StateConflictError{Entity: "booking", Key: bookingID, Reason: "invoice is available only for paid bookings"}
+	}
+
+	now := time.Now()
+	series := strconv.Itoa(now.Year())
+	seq, err := nextInvoiceNumber(tx, series)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+
+	guest, err := billingDetails(tx, booking.UserID)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+	host, err := billingDetails(tx, booking.Apartment.OwnerID)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+
+	invoice = models.Invoice{
+		ID:              uuid.New().String(),
+		Number:          fmt.Sprintf("%s-%s-%06d", invoicePrefix, series, seq),
+		Series:          series,
+		Seq:             seq,
+		BookingID:       booking.ID,
+		PaymentID:       payment.ID,
+		IssuedAt:        now,
+		Currency:        payment.Currency,
+		GuestID:         booking.UserID,
+		Guest:           guest,
+		HostID:          booking.Apartment.OwnerID,
+		Host:            host,
+		PropertyAddress: booking.Apartment.Address,
+		TimeFrom:        booking.TimeFrom,
+		TimeTo:          booking.TimeTo,
+		TimeZone:        booking.Apartment.TimeZone,
+		Nights:          stayNights(booking.TimeFrom, booking.TimeTo, booking.Apartment.Location()),
+		Lines:           invoiceLines(&booking, &payment),
+	}
+	for _, line := range invoice.Lines {
+		// строка налога города сама является налогом
+		if line.Kind == models.LineCityTax {
+			invoice.TaxTotal = invoice.TaxTotal.Add(line.Amount)
+			continue
+		}
+		invoice.Subtotal = invoice.Subtotal.Add(line.Amount)
+		invoice.TaxTotal = invoice.TaxTotal.Add(line.TaxAmount)
+	}
+	invoice.Total = invoice.Subtotal.Add(invoice.TaxTotal)
+
+	if err := tx.Create(&invoice).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Invoice{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.Invoice{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Issued invoice %s for booking %s", invoice.Number, bookingID)
+	return invoice, nil
+}
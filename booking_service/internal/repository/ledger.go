@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/money"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// payoutLockKey — ключ pg_advisory_xact_lock: выплаты формирует один экземпляр за раз
+const payoutLockKey = 4205
+
+func entry(account string, ownerID *string, amount int64, currency string) models.LedgerEntry {
+	return models.LedgerEntry{Account: account, OwnerID: ownerID, Amount: amount, Currency: currency}
+}
+
+// postLedger проводит операцию, если такой (kind, reference) ещё не было.
+// false — операция уже проведена раньше
+func postLedger(db *gorm.DB, txn *models.LedgerTransaction, entries []models.LedgerEntry) (bool, error) {
+	sums := map[string]int64{}
+	for _, e := range entries {
+		sums[e.Currency] += e.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return false, fmt.Errorf("unbalanced %s transaction %s: %d %s", txn.Kind, txn.Reference, sum, currency)
+		}
+	}
+
+	txn.ID = uuid.New().String()
+	txn.CreatedAt = time.Now()
+	res := db.Omit("Entries").Clauses(clause.OnConflict{DoNothing: true}).Create(txn)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	for i := range entries {
+		entries[i].TransactionID = txn.ID
+		entries[i].CreatedAt = txn.CreatedAt
+	}
+	return true, db.Create(&entries).Error
+}
+
+// postCharge: гость заплатил Amount. Сервисный сбор и налог города остаются у платформы,
+// с проживания и уборки удерживается комиссия, остальное ждёт заезда на счёте хоста
+func postCharge(db *gorm.DB, payment *models.Payment, ownerID string) error {
+	var lines []models.BookingLineItem
+	if err := db.Where("booking_id = ?", payment.BookingID).Find(&lines).Error; err != nil {
+		return err
+	}
+
+	var serviceFee, cityTax int64
+	for _, l := range lines {
+		switch l.Kind {
+		case models.LineServiceFee:
+			serviceFee += money.ToMinor(l.Amount, l.Currency)
+		case models.LineCityTax:
+			cityTax += money.ToMinor(l.Amount, l.Currency)
+		}
+	}
+	hostBase := payment.Amount - serviceFee - cityTax
+	commission := hostBase * int64(payment.CommissionBps) / 10000
+
+	entries := []models.LedgerEntry{
+		entry(models.AccountPlatformCash, nil, payment.Amount, payment.Currency),
+		entry(models.AccountCommission, nil, -commission, payment.Currency),
+		entry(models.HostPendingAccount(ownerID), &ownerID, -(hostBase - commission), payment.Currency),
+	}
+	if serviceFee > 0 {
+		entries = append(entries, entry(models.AccountServiceFees, nil, -serviceFee, payment.Currency))
+	}
+	if cityTax > 0 {
+		entries = append(entries, entry(models.AccountTaxPayable, nil, -cityTax, payment.Currency))
+	}
+
+	_, err := postLedger(db, &models.LedgerTransaction{
+		Kind:      models.LedgerCharge,
+		Reference: payment.ID,
+		BookingID: &payment.BookingID,
+		OwnerID:   ownerID,
+	}, entries)
+	return err
+}
+
+// postRefund сторнирует списание. Если доля хоста уже освобождена, она забирается с доступного остатка
+func postRefund(db *gorm.DB, payment *models.Payment) error {
+	var charge models.LedgerTransaction
+	err := db.Preload("Entries").Where("kind = ? AND reference = ?", models.LedgerCharge, payment.ID).First(&charge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var released int64
+	if err := db.Model(&models.LedgerTransaction{}).
+		Where("kind = ? AND reference = ?", models.LedgerRelease, payment.BookingID).
+		Count(&released).Error; err != nil {
+		return err
+	}
+
+	entries := make([]models.LedgerEntry, 0, len(charge.Entries))
+	for _, e := range charge.Entries {
+		account := e.Account
+		if released > 0 && account == models.HostPendingAccount(charge.OwnerID) {
+			account = models.HostAvailableAccount(charge.OwnerID)
+		}
+		entries = append(entries, entry(account, e.OwnerID, -e.Amount, e.Currency))
+	}
+
+	_, err = postLedger(db, &models.LedgerTransaction{
+		Kind:      models.LedgerRefund,
+		Reference: payment.ID,
+		BookingID: &payment.BookingID,
+		OwnerID:   charge.OwnerID,
+	}, entries)
+	return err
+}
+
+// ReleaseHostFunds делает доступной к выплате долю хоста по подтверждённым броням с заездом
+// до checkedInBefore
+func (r *repositoryWithTM) ReleaseHostFunds(checkedInBefore time.Time) (int, error) {
+	var due []struct {
+		BookingID string
+		OwnerID   string
+		Currency  string
+		Amount    int64
+	}
+	if err := r.tm.db.Raw(`SELECT t.booking_id, e.owner_id, e.currency, -SUM(e.amount) AS amount
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.transaction_id = e.transaction_id
+		JOIN bookings b ON b.booking_id = t.booking_id
+		WHERE e.account LIKE 'host_pending:%' AND b.status = ? AND b.time_from <= ?
+			AND NOT EXISTS (SELECT 1 FROM ledger_transactions rt WHERE rt.kind = ? AND rt.reference = t.booking_id)
+		GROUP BY t.booking_id, e.owner_id, e.currency
+		HAVING SUM(e.amount) <> 0`, models.BookingConfirmed, checkedInBefore, models.LedgerRelease).
+		Scan(&due).Error; err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, d := range due {
+		ownerID := d.OwnerID
+		bookingID := d.BookingID
+
+		// TRANSACTION [BEGIN]
+		tx, err := r.tm.begin()
+		if err != nil {
+			return released, err
+		}
+
+		// бронь могли отменить после выборки: отмена держит ту же строку FOR UPDATE
+		var booking models.Booking
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", bookingID).First(&booking).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return released, err
+		}
+		if booking.Status != models.BookingConfirmed {
+			_ = r.tm.rollback(tx)
+			continue
+		}
+
+		posted, err := postLedger(tx, &models.LedgerTransaction{
+			Kind:      models.LedgerRelease,
+			Reference: bookingID,
+			BookingID: &bookingID,
+			OwnerID:   ownerID,
+		}, []models.LedgerEntry{
+			entry(models.HostPendingAccount(ownerID), &ownerID, d.Amount, d.Currency),
+			entry(models.HostAvailableAccount(ownerID), &ownerID, -d.Amount, d.Currency),
+		})
+		if err != nil {
+			_ = r.tm.rollback(tx)
+			return released, err
+		}
+
+		// COMMIT (TRANSACTION END)
+		if err := r.tm.commit(tx); err != nil {
+			return released, err
+		}
+		if posted {
+			released++
+		}
+	}
+	return released, nil
+}
+
+// CreatePayouts выплачивает каждому хосту весь положительный доступный остаток
+func (r *repositoryWithTM) CreatePayouts() ([]models.Payout, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", payoutLockKey).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	var due []struct {
+		OwnerID  string
+		Currency string
+		Amount   int64
+	}
+	if err := tx.Raw(`SELECT owner_id, currency, -SUM(amount) AS amount
+		FROM ledger_entries
+		WHERE account LIKE 'host_available:%'
+		GROUP BY owner_id, currency
+		HAVING -SUM(amount) > 0`).
+		Scan(&due).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	payouts := make([]models.Payout, 0, len(due))
+	for _, d := range due {
+		ownerID := d.OwnerID
+		payout := models.Payout{
+			ID:        uuid.New().String(),
+			OwnerID:   ownerID,
+			Amount:    d.Amount,
+			Currency:  d.Currency,
+			CreatedAt: time.Now(),
+		}
+		if err := tx.Create(&payout).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return nil, err
+		}
+
+		if _, err := postLedger(tx, &models.LedgerTransaction{
+			Kind:      models.LedgerPayout,
+			Reference: payout.ID,
+			OwnerID:   ownerID,
+		}, []models.LedgerEntry{
+			entry(models.HostAvailableAccount(ownerID), &ownerID, d.Amount, d.Currency),
+			entry(models.AccountPlatformCash, nil, -d.Amount, d.Currency),
+		}); err != nil {
+			_ = r.tm.rollback(tx)
+			return nil, err
+		}
+		payouts = append(payouts, payout)
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return nil, err
+	}
+
+	if len(payouts) > 0 {
+		logrus.WithTime(time.Now()).Infof("Created %d payouts", len(payouts))
+	}
+	return payouts, nil
+}
+
+func (r *repositoryWithTM) GetOwnerBalance(ownerID string) ([]models.OwnerBalance, error) {
+	var balances []models.OwnerBalance
+	if err := r.tm.db.Raw(`SELECT currency,
+			COALESCE(-SUM(amount) FILTER (WHERE account = @pending), 0) AS pending,
+			COALESCE(-SUM(amount) FILTER (WHERE account = @available), 0) AS available,
+			COALESCE((SELECT SUM(p.amount) FROM payouts p
+				WHERE p.owner_id = @owner AND p.currency = ledger_entries.currency), 0) AS paid_out
+		FROM ledger_entries
+		WHERE owner_id = @owner
+		GROUP BY currency
+		ORDER BY currency`,
+		map[string]any{
+			"owner":     ownerID,
+			"pending":   models.HostPendingAccount(ownerID),
+			"available": models.HostAvailableAccount(ownerID),
+		}).
+		Scan(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r *repositoryWithTM) GetOwnerPayouts(ownerID string, page dtos.PageRequest) (*[]models.Payout, dtos.PageInfo, error) {
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Payout{}).Where("owner_id = ?", ownerID).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Where("owner_id = ?", ownerID)
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(created_at, payout_id) < (?, ?)", createdAt, cursor.ID)
+	}
+
+	var payouts []models.Payout
+	limit := pageLimit(page.Limit)
+	if err := db.Order("created_at DESC").Order("payout_id DESC").Limit(limit + 1).Find(&payouts).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(payouts) > limit {
+		payouts = payouts[:limit]
+		last := payouts[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	return &payouts, info, nil
+}
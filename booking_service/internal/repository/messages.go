@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// threadFor загружает переписку с апартаментом и пускает гостя, владельца и соавторов
+// с правом manage_bookings
+func threadFor(db *gorm.DB, threadID, userID string) (models.Thread, error) {
+	var t models.Thread
+	if err := db.Preload("Apartment").Where("thread_id = ?", threadID).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Thread{}, &servererrors.NotFoundError{Entity: "thread", Key: threadID}
+		}
+		return models.Thread{}, err
+	}
+
+	if userID != t.GuestID {
+		if err := authorize(db, &t.Apartment, userID, models.PermManageBookings); err != nil {
+			return models.Thread{}, err
+		}
+	}
+
+	return t, nil
+}
+
+func (r *repositoryWithTM) StartInquiry(apID string, dto *dtos.InquiryDTO) (models.Thread, models.Message, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Thread{}, models.Message{}, err
+	}
+
+	var ap models.Apartment
+	if err := tx.Where("id = ?", apID).First(&ap).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Thread{}, models.Message{}, &servererrors.NotFoundError{Entity: "apartment", Key: apID}
+		}
+		return models.Thread{}, models.Message{}, err
+	}
+
+	if !ap.IsVisible() {
+		_ = r.tm.rollback(tx)
+		return models.Thread{}, models.Message{}, &servererrors.NotFoundError{Entity: "apartment", Key: apID}
+	}
+	if ap.OwnerID == dto.UserID {
+		_ = r.tm.rollback(tx)
+		return models.Thread{}, models.Message{}, &servererrors.BadRequestError{Violation: "owner cannot send an inquiry to own apartment"}
+	}
+
+	now := time.Now()
+	thread := models.Thread{
+		ID:            uuid.New().String(),
+		ApartmentID:   apID,
+		GuestID:       dto.UserID,
+		CreatedAt:     now,
+		LastMessageAt: now,
+	}
+
+	// одна переписка-запрос на пару (апартамент, гость)
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "ap_id"}, {Name: "guest_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "booking_id IS NULL"}}},
+		DoUpdates:   clause.Assignments(map[string]any{"last_message_at": now}),
+	}).Omit("Apartment").Create(&thread).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Thread{}, models.Message{}, err
+	}
+
+	if err := tx.Where("ap_id = ? AND guest_id = ? AND booking_id IS NULL", apID, dto.UserID).First(&thread).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Thread{}, models.Message{}, err
+	}
+
+	msg := models.Message{
+		ID:        uuid.New().String(),
+		ThreadID:  thread.ID,
+		SenderID:  dto.UserID,
+		Body:      dto.Body,
+		CreatedAt: now,
+	}
+	if err := tx.Omit("Thread").Create(&msg).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Thread{}, models.Message{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.Thread{}, models.Message{}, err
+	}
+
+	thread.Apartment = ap
+	return thread, msg, nil
+}
+
+func (r *repositoryWithTM) GetBookingThread(bookingID, userID string) (models.Thread, error) {
+	var b models.Booking
+	if err := r.tm.db.Preload("Apartment").Where("booking_id = ?", bookingID).First(&b).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Thread{}, &servererrors.NotFoundError{Entity: "booking", Key: bookingID}
+		}
+		return models.Thread{}, err
+	}
+
+	if userID != b.UserID {
+		if err := authorize(r.tm.db, &b.Apartment, userID, models.PermManageBookings); err != nil {
+			return models.Thread{}, err
+		}
+	}
+
+	now := time.Now()
+	thread := models.Thread{
+		ID:            uuid.New().String(),
+		ApartmentID:   b.ApartmentID,
+		BookingID:     &b.ID,
+		GuestID:       b.UserID,
+		CreatedAt:     now,
+		LastMessageAt: now,
+	}
+	if err := r.tm.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "booking_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "booking_id IS NOT NULL"}}},
+		DoNothing:   true,
+	}).Omit("Apartment").Create(&thread).Error; err != nil {
+		return models.Thread{}, err
+	}
+
+	if err := r.tm.db.Where("booking_id = ?", bookingID).First(&thread).Error; err != nil {
+		return models.Thread{}, err
+	}
+
+	thread.Apartment = b.Apartment
+	return thread, nil
+}
+
+// GetThreadsByUser — переписки, где пользователь гость, владелец или соавтор с правом
+// manage_bookings, свежие первыми;
+// unread — сообщения собеседника после последнего прочтения
+func (r *repositoryWithTM) GetThreadsByUser(userID string, page dtos.PageRequest) (*[]models.Thread, dtos.PageInfo, error) {
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	participant := func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN apartments ON apartments.id = message_threads.ap_id").
+			Where(`message_threads.guest_id = ? OR apartments.owner_id = ? OR EXISTS (SELECT 1 FROM apartment_cohosts ch
+				WHERE ch.ap_id = message_threads.ap_id AND ch.user_id = ? AND ch.can_manage_bookings)`, userID, userID, userID)
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Thread{}).Scopes(participant).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Scopes(participant).
+		Preload("Apartment").
+		Select(`message_threads.*, (SELECT count(*) FROM messages m
+			LEFT JOIN message_thread_reads tr ON tr.thread_id = m.thread_id AND tr.user_id = ?
+			WHERE m.thread_id = message_threads.thread_id AND m.sender_id <> ?
+			AND (tr.last_read_at IS NULL OR m.created_at > tr.last_read_at)) AS unread`, userID, userID)
+
+	if cursor != nil {
+		lastAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(message_threads.last_message_at, message_threads.thread_id) < (?, ?)", lastAt, cursor.ID)
+	}
+
+	var threads []models.Thread
+	limit := pageLimit(page.Limit)
+	if err := db.Order("message_threads.last_message_at DESC").Order("message_threads.thread_id DESC").
+		Limit(limit + 1).Find(&threads).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(threads) > limit {
+		threads = threads[:limit]
+		last := threads[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.LastMessageAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	return &threads, info, nil
+}
+
+func (r *repositoryWithTM) CheckThreadParticipant(threadID, userID string) (models.Thread, error) {
+	return threadFor(r.tm.db, threadID, userID)
+}
+
+// GetThreadMessages — сообщения новые первыми и отметки прочтения обоих участников
+func (r *repositoryWithTM) GetThreadMessages(threadID, userID string, page dtos.PageRequest) (*[]models.Message, []models.ThreadRead, dtos.PageInfo, error) {
+	if _, err := threadFor(r.tm.db, threadID, userID); err != nil {
+		return nil, nil, dtos.PageInfo{}, err
+	}
+
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Preload("Attachments").Where("thread_id = ?", threadID)
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(created_at, message_id) < (?, ?)", createdAt, cursor.ID)
+	}
+
+	var messages []models.Message
+	limit := pageLimit(page.Limit)
+	if err := db.Order("created_at DESC").Order("message_id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, nil, dtos.PageInfo{}, err
+	}
+
+	var reads []models.ThreadRead
+	if err := r.tm.db.Where("thread_id = ?", threadID).Find(&reads).Error; err != nil {
+		return nil, nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	return &messages, reads, info, nil
+}
+
+// GetThreadAttachment — вложение сообщения из этой переписки; права проверяются как для чтения сообщений
+func (r *repositoryWithTM) GetThreadAttachment(threadID, attachmentID, userID string) (models.MessageAttachment, error) {
+	if _, err := threadFor(r.tm.db, threadID, userID); err != nil {
+		return models.MessageAttachment{}, err
+	}
+
+	var a models.MessageAttachment
+	err := r.tm.db.Joins("JOIN messages m ON m.message_id = message_attachments.message_id").
+		Where("m.thread_id = ? AND message_attachments.attachment_id = ?", threadID, attachmentID).
+		First(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.MessageAttachment{}, &servererrors.NotFoundError{Entity: "attachment", Key: attachmentID}
+	}
+	return a, err
+}
+
+// AddMessage сохраняет сообщение с вложениями (файлы уже лежат в хранилище)
+func (r *repositoryWithTM) AddMessage(msg *models.Message) error {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := threadFor(tx, msg.ThreadID, msg.SenderID); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	msg.CreatedAt = time.Now()
+	if err := tx.Omit("Thread").Create(msg).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	if err := tx.Model(&models.Thread{}).Where("thread_id = ?", msg.ThreadID).
+		Update("last_message_at", msg.CreatedAt).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	// своё сообщение автор уже прочитал
+	if err := upsertThreadRead(tx, msg.ThreadID, msg.SenderID, msg.CreatedAt); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	// COMMIT (TRANSACTION END)
+	return r.tm.commit(tx)
+}
+
+func (r *repositoryWithTM) MarkThreadRead(threadID, userID string) error {
+	if _, err := threadFor(r.tm.db, threadID, userID); err != nil {
+		return err
+	}
+	return upsertThreadRead(r.tm.db, threadID, userID, time.Now())
+}
+
+func upsertThreadRead(db *gorm.DB, threadID, userID string, at time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "thread_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"last_read_at": gorm.Expr("GREATEST(message_thread_reads.last_read_at, EXCLUDED.last_read_at)"),
+		}),
+	}).Create(&models.ThreadRead{ThreadID: threadID, UserID: userID, LastReadAt: at}).Error
+}
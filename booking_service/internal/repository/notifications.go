@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *repositoryWithTM) GetNotificationPreferences(userID string) (models.NotificationPreference, error) {
+	prefs := models.DefaultNotificationPreference(userID)
+	err := r.tm.db.Where("user_id = ?", userID).First(&prefs).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.NotificationPreference{}, err
+	}
+	return prefs, nil
+}
+
+func (r *repositoryWithTM) UpdateNotificationPreferences(userID string, dto *dtos.NotificationPreferencesDTO) (models.NotificationPreference, error) {
+	prefs, err := r.GetNotificationPreferences(userID)
+	if err != nil {
+		return models.NotificationPreference{}, err
+	}
+
+	if dto.Locale != nil {
+		prefs.Locale = *dto.Locale
+	}
+	if dto.Email != nil {
+		prefs.Email = *dto.Email
+	}
+	if dto.Push != nil {
+		prefs.Push = *dto.Push
+	}
+	if dto.InApp != nil {
+		prefs.InApp = *dto.InApp
+	}
+	prefs.UpdatedAt = time.Now()
+
+	if err := r.tm.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"locale", "email", "push", "in_app", "updated_at"}),
+	}).Create(&prefs).Error; err != nil {
+		return models.NotificationPreference{}, err
+	}
+
+	return prefs, nil
+}
+
+// GetNotifications — лента in-app уведомлений, новые первыми
+func (r *repositoryWithTM) GetNotifications(userID string, q dtos.NotificationQuery) (*[]models.Notification, dtos.PageInfo, error) {
+	cursor, err := decodeCursor(q.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ? AND in_app", userID)
+		if q.Unread {
+			db = db.Where("read_at IS NULL")
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Notification{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Scopes(scope)
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(created_at, notification_id) < (?, ?)", createdAt, cursor.ID)
+	}
+
+	var notifications []models.Notification
+	limit := pageLimit(q.Limit)
+	if err := db.Order("created_at DESC").Order("notification_id DESC").Limit(limit + 1).Find(&notifications).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	return &notifications, info, nil
+}
+
+func (r *repositoryWithTM) MarkNotificationRead(id, userID string) error {
+	var n models.Notification
+	if err := r.tm.db.Where("notification_id = ?", id).First(&n).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &servererrors.NotFoundError{Entity: "notification", Key: id}
+		}
+		return err
+	}
+
+	if n.UserID != userID {
+		return &servererrors.ForbiddenAccessError{UserId: userID, ResourceType: "notification", ResourceId: id}
+	}
+
+	if n.ReadAt != nil {
+		return nil
+	}
+	return r.tm.db.Model(&n).Update("read_at", time.Now()).Error
+}
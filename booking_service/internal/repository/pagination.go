@@ -0,0 +1,51 @@
+package repository
+
+import (
+	servererrors "booking_service/internal/server_errors"
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// pageCursor — позиция последней отданной записи: значение ключа сортировки и id
+type pageCursor struct {
+	Key string `json:"k,omitempty"`
+	ID  string `json:"id"`
+}
+
+func encodeCursor(c pageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, &servererrors.BadRequestError{Violation: "malformed cursor"}
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, &servererrors.BadRequestError{Violation: "malformed cursor"}
+	}
+
+	return &c, nil
+}
+
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
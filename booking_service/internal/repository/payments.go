@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paymentTransitions — допустимые переходы; всё остальное (повтор, устаревшее событие) игнорируется
+var paymentTransitions = map[string][]string{
+	models.PaymentPending:    {models.PaymentAuthorized, models.PaymentCaptured, models.PaymentFailed, models.PaymentVoided},
+	models.PaymentAuthorized: {models.PaymentCaptured, models.PaymentFailed, models.PaymentVoided},
+	models.PaymentCaptured:   {models.PaymentRefunded},
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range paymentTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *repositoryWithTM) ApplyPaymentUpdate(dto *dtos.PaymentUpdateDTO) (models.Payment, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Payment{}, err
+	}
+
+	var payment models.Payment
+	q := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	if dto.PaymentID != "" {
+		q = q.Where("payment_id = ?", dto.PaymentID)
+	} else {
+		q = q.Where("provider = ? AND provider_ref = ? AND provider_ref <> ''", dto.Provider, dto.ProviderRef)
+	}
+	if err := q.First(&payment).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Payment{}, &servererrors.NotFoundError{Entity: "payment", Key: dto.PaymentID + dto.ProviderRef}
+		}
+		return models.Payment{}, err
+	}
+
+	// повторная доставка того же вебхука — ничего не меняем
+	if dto.EventID != "" {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PaymentWebhookEvent{
+			Provider:   payment.Provider,
+			EventID:    dto.EventID,
+			PaymentID:  payment.ID,
+			ReceivedAt: time.Now(),
+		})
+		if res.Error != nil {
+			_ = r.tm.rollback(tx)
+			return models.Payment{}, res.Error
+		}
+		if res.RowsAffected == 0 {
+			_ = r.tm.rollback(tx)
+			return r.GetBookingPayment(payment.BookingID)
+		}
+	}
+
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Joins("Apartment").
+		Where("bookings.booking_id = ?", payment.BookingID).
+		First(&booking).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Payment{}, err
+	}
+
+	updates := map[string]any{}
+	if payment.ProviderRef == "" && dto.ProviderRef != "" {
+		payment.ProviderRef = dto.ProviderRef
+		updates["provider_ref"] = payment.ProviderRef
+	}
+	changed := canTransition(payment.Status, dto.Status)
+	if changed {
+		payment.Status = dto.Status
+		updates["status"] = payment.Status
+		if dto.Reason != "" {
+			payment.FailureReason = dto.Reason
+			updates["failure_reason"] = payment.FailureReason
+		}
+		if payment.Status == models.PaymentRefunded {
+			payment.RefundedAmount = payment.Amount
+			updates["refunded_amount"] = payment.RefundedAmount
+		}
+	}
+	payment.UpdatedAt = time.Now()
+	updates["updated_at"] = payment.UpdatedAt
+
+	if err := tx.Model(&payment).Updates(updates).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Payment{}, err
+	}
+
+	// деньги движутся только при списании и возврате — их и проводим в книге
+	if changed && payment.Status == models.PaymentCaptured {
+		err = postCharge(tx, &payment, booking.Apartment.OwnerID)
+	} else if changed && payment.Status == models.PaymentRefunded {
+		err = postRefund(tx, &payment)
+	}
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Payment{}, err
+	}
+
+	// бронь реагирует только пока ждёт оплаты; поздние события разбирает вызывающий
+	if booking.Status == models.BookingPendingPayment {
+		var eventType string
+		switch payment.Status {
+		case models.PaymentAuthorized, models.PaymentCaptured:
+			booking.Status = models.BookingConfirmed
+			eventType = events.BookingConfirmed
+		case models.PaymentFailed, models.PaymentVoided:
+			booking.Status = models.BookingPaymentFailed
+			eventType = events.BookingPaymentFailed
+		}
+
+		if eventType != "" {
+			if err := tx.Model(&booking).Update("status", booking.Status).Error; err != nil {
+				_ = r.tm.rollback(tx)
+				return models.Payment{}, err
+			}
+			if err := r.tm.emit(tx, eventType, booking.ID, bookingPayload(&booking, booking.Apartment.OwnerID)); err != nil {
+				_ = r.tm.rollback(tx)
+				return models.Payment{}, err
+			}
+		}
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.Payment{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Payment %s is %s, booking %s is %s", payment.ID, payment.Status, booking.ID, booking.Status)
+	payment.Booking = booking
+	return payment, nil
+}
+
+func (r *repositoryWithTM) GetBookingPayment(bookingID string) (models.Payment, error) {
+	var payment models.Payment
+	if err := r.tm.db.Preload("Booking.Apartment").Where("booking_id = ?", bookingID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Payment{}, &servererrors.NotFoundError{Entity: "payment for booking", Key: bookingID}
+		}
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+// GetPaymentsToReconcile: платёж ещё не списан или списан за бронь, которой уже нет
+func (r *repositoryWithTM) GetPaymentsToReconcile(before time.Time, limit int) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.tm.db.Preload("Booking.Apartment").
+		Joins("JOIN bookings b ON b.booking_id = payments.booking_id").
+		Where("payments.updated_at < ?", before).
+		Where(`(payments.status IN ? OR (payments.status = ? AND b.status IN ?))`,
+			[]string{models.PaymentPending, models.PaymentAuthorized},
+			models.PaymentCaptured, []string{models.BookingCancelled, models.BookingPaymentFailed}).
+		Order("payments.updated_at").
+		Limit(limit).
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
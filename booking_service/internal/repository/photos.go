@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func (r *repositoryWithTM) AddApartmentPhoto(ownerID string, photo *models.ApartmentPhoto) error {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := lockApartmentFor(tx, photo.ApartmentID, ownerID, models.PermEditListing); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	var stats struct {
+		Count       int64
+		MaxPosition int
+	}
+	if err := tx.Model(&models.ApartmentPhoto{}).
+		Select("count(*) AS count, coalesce(max(position), 0) AS max_position").
+		Where("ap_id = ?", photo.ApartmentID).
+		Scan(&stats).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	photo.Position = stats.MaxPosition + 1
+	photo.IsCover = stats.Count == 0
+	photo.CreatedAt = time.Now()
+
+	if err := tx.Create(photo).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Successfuly added photo %s to apartment %s", photo.ID, photo.ApartmentID)
+	return nil
+}
+
+func (r *repositoryWithTM) GetApartmentPhotos(apID string) ([]models.ApartmentPhoto, error) {
+	var photos []models.ApartmentPhoto
+	if err := r.tm.db.Where("ap_id = ?", apID).Order("position").Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (r *repositoryWithTM) ReorderApartmentPhotos(apID string, dto *dtos.PhotoOrderDTO) ([]models.ApartmentPhoto, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := lockApartmentFor(tx, apID, dto.OwnerID, models.PermEditListing); err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	var photos []models.ApartmentPhoto
+	if err := tx.Where("ap_id = ?", apID).Find(&photos).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return nil, err
+	}
+
+	byID := make(map[string]*models.ApartmentPhoto, len(photos))
+	for i := range photos {
+		byID[photos[i].ID] = &photos[i]
+	}
+
+	ve := &servererrors.ValidationError{}
+	if len(dto.PhotoIDs) != len(photos) {
+		ve.Add("photo_ids", "must list every photo of the apartment exactly once")
+	}
+	seen := map[string]bool{}
+	for _, id := range dto.PhotoIDs {
+		if byID[id] == nil {
+			ve.Add("photo_ids", "unknown photo "+id)
+		} else if seen[id] {
+			ve.Add("photo_ids", "duplicate photo "+id)
+		}
+		seen[id] = true
+	}
+	if len(ve.Fields) > 0 {
+		_ = r.tm.rollback(tx)
+		return nil, ve
+	}
+
+	ordered := make([]models.ApartmentPhoto, 0, len(photos))
+	for i, id := range dto.PhotoIDs {
+		photo := byID[id]
+		photo.Position = i + 1
+		if err := tx.Model(photo).Update("position", photo.Position).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return nil, err
+		}
+		ordered = append(ordered, *photo)
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return nil, err
+	}
+
+	return ordered, nil
+}
+
+func (r *repositoryWithTM) SetApartmentCover(apID, photoID, ownerID string) error {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := lockApartmentFor(tx, apID, ownerID, models.PermEditListing); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	res := tx.Model(&models.ApartmentPhoto{}).
+		Where("ap_id = ? AND photo_id = ?", apID, photoID).
+		Update("is_cover", true)
+	if res.Error != nil {
+		_ = r.tm.rollback(tx)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		_ = r.tm.rollback(tx)
+		return &servererrors.NotFoundError{Entity: "photo", Key: photoID}
+	}
+
+	if err := tx.Model(&models.ApartmentPhoto{}).
+		Where("ap_id = ? AND photo_id <> ?", apID, photoID).
+		Update("is_cover", false).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	// COMMIT (TRANSACTION END)
+	return r.tm.commit(tx)
+}
+
+func (r *repositoryWithTM) DeleteApartmentPhoto(apID, photoID, ownerID string) (models.ApartmentPhoto, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.ApartmentPhoto{}, err
+	}
+
+	if _, err := lockApartmentFor(tx, apID, ownerID, models.PermEditListing); err != nil {
+		_ = r.tm.rollback(tx)
+		return models.ApartmentPhoto{}, err
+	}
+
+	var photo models.ApartmentPhoto
+	if err := tx.Where("ap_id = ? AND photo_id = ?", apID, photoID).First(&photo).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ApartmentPhoto{}, &servererrors.NotFoundError{Entity: "photo", Key: photoID}
+		}
+		return models.ApartmentPhoto{}, err
+	}
+
+	if err := tx.Delete(&photo).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.ApartmentPhoto{}, err
+	}
+
+	if err := tx.Model(&models.ApartmentPhoto{}).
+		Where("ap_id = ? AND position > ?", apID, photo.Position).
+		Update("position", gorm.Expr("position - 1")).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.ApartmentPhoto{}, err
+	}
+
+	// обложкой становится первая из оставшихся
+	if photo.IsCover {
+		if err := tx.Model(&models.ApartmentPhoto{}).
+			Where("ap_id = ? AND position = 1", apID).
+			Update("is_cover", true).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return models.ApartmentPhoto{}, err
+		}
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.ApartmentPhoto{}, err
+	}
+
+	return photo, nil
+}
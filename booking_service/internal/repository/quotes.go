@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/money"
+	servererrors "booking_service/internal/server_errors"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// maxPrice — предел колонки decimal(10,2)
+var maxPrice = decimal.RequireFromString("99999999.99")
+
+func validatePrice(ve *servererrors.ValidationError, price decimal.Decimal, currency string) {
+	if !money.IsSupported(currency) {
+		ve.Add("currency", "unsupported currency "+currency)
+		return
+	}
+	if !price.IsPositive() {
+		ve.Add("price", "must be greater than 0")
+	} else if price.GreaterThan(maxPrice) {
+		ve.Add("price", "must not exceed "+maxPrice.String())
+	}
+	if !price.Equal(money.Round(price, currency)) {
+		ve.Add("price", "has more decimal places than "+currency+" allows")
+	}
+}
+
+// calendarNights — число ночей между датами заезда и выезда, без учёта часов. Даты берутся
+// в поясе from и to, поэтому переход на летнее время не добавляет и не съедает ночь
+func calendarNights(from, to time.Time) int {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	days := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC))
+	return int(math.Round(days.Hours() / 24))
+}
+
+// localDate — дата момента t в поясе loc (полночь UTC, как её хранит колонка date)
+func localDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// stayNights — оплачиваемые ночи по местным датам; заезд и выезд в один день считаются за ночь
+func stayNights(from, to time.Time, loc *time.Location) int {
+	nights := calendarNights(from.In(loc), to.In(loc))
+	if nights < 1 {
+		nights = 1
+	}
+	return nights
+}
+
+// validateFee — необязательный сбор: неотрицательный и не точнее валюты
+func validateFee(ve *servererrors.ValidationError, field string, fee decimal.Decimal, currency string) {
+	if fee.IsNegative() {
+		ve.Add(field, "must not be negative")
+	} else if fee.GreaterThan(maxPrice) {
+		ve.Add(field, "must not exceed "+maxPrice.String())
+	}
+	if !fee.Equal(money.Round(fee, currency)) {
+		ve.Add(field, "has more decimal places than "+currency+" allows")
+	}
+}
+
+func guestsFrom(dto dtos.GuestsDTO) models.Guests {
+	g := models.Guests{Adults: dto.Adults, Children: dto.Children, Infants: dto.Infants, Pets: dto.Pets}
+	if g.Adults == 0 {
+		g.Adults = 1
+	}
+	return g
+}
+
+// checkOccupancy сверяет состав гостей с вместимостью и правилами текущего описания.
+// Без описания ограничений нет
+func checkOccupancy(db *gorm.DB, apID string, g models.Guests) error {
+	var desc models.Description
+	err := db.Scopes(descriptionsAt(nil)).Where("ap_id = ?", apID).First(&desc).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ve := &servererrors.ValidationError{}
+	if capacity := desc.MaxGuests(); capacity > 0 && g.Count() > capacity {
+		ve.Add("guests", fmt.Sprintf("apartment accommodates at most %d guests, requested %d", capacity, g.Count()))
+	}
+	if g.Pets > 0 && !desc.PetsAllowed() {
+		ve.Add("pets", "pets are not allowed in this apartment")
+	}
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+	return nil
+}
+
+// quoteStay — стоимость проживания по текущей цене объявления и правилам сборов;
+// по ней же считается оплата брони
+func (r *repositoryWithTM) quoteStay(ap *models.Apartment, from, to time.Time, guests models.Guests) (models.Quote, error) {
+	nights := stayNights(from, to, ap.Location())
+	lines, err := r.fees.Lines(context.Background(), ap, nights, guests.Count())
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	total := decimal.Zero
+	for _, l := range lines {
+		total = total.Add(l.Amount)
+	}
+
+	return models.Quote{
+		ApartmentID:   ap.ID,
+		TimeFrom:      from,
+		TimeTo:        to,
+		TimeZone:      ap.TimeZone,
+		Nights:        nights,
+		Guests:        guests,
+		Currency:      ap.Currency,
+		PricePerNight: ap.Price,
+		Lines:         lines,
+		Total:         total,
+	}, nil
+}
+
+func (r *repositoryWithTM) QuoteStay(apID string, stay dtos.StayDTO, dto dtos.GuestsDTO) (models.Quote, error) {
+	var ap models.Apartment
+	if err := r.tm.db.Where("id = ?", apID).First(&ap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Quote{}, &servererrors.NotFoundError{Entity: "apartment", Key: apID}
+		}
+		return models.Quote{}, err
+	}
+
+	if !ap.IsVisible() {
+		return models.Quote{}, &servererrors.StateConflictError{Entity: "apartment", Key: ap.ID, Reason: "apartment is not open for booking"}
+	}
+
+	from, to, err := stayWindow(r.tm.db, &ap, stay)
+	if err != nil {
+		return models.Quote{}, err
+	}
+	guests := guestsFrom(dto)
+	if err := checkOccupancy(r.tm.db, ap.ID, guests); err != nil {
+		return models.Quote{}, err
+	}
+
+	return r.quoteStay(&ap, from, to, guests)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestCalendarNights(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		from, to string
+		want     int
+	}{
+		{"berlin spring forward", "Europe/Berlin", "2026-03-29 00:00", "2026-03-30 00:00", 1},
+		{"berlin week over spring forward", "Europe/Berlin", "2026-03-25 15:00", "2026-04-01 11:00", 7},
+		{"berlin fall back", "Europe/Berlin", "2026-10-25 00:00", "2026-10-26 00:00", 1},
+		{"berlin week over fall back", "Europe/Berlin", "2026-10-20 15:00", "2026-10-27 11:00", 7},
+		{"new york spring forward", "America/New_York", "2026-03-08 00:00", "2026-03-09 00:00", 1},
+		{"new york fall back", "America/New_York", "2026-11-01 00:00", "2026-11-02 00:00", 1},
+		{"new york late check-in", "America/New_York", "2026-10-31 23:30", "2026-11-02 11:00", 2},
+		{"same day", "Europe/Berlin", "2026-03-29 09:00", "2026-03-29 18:00", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustZone(t, tt.zone)
+			from, _ := time.ParseInLocation("2006-01-02 15:04", tt.from, loc)
+			to, _ := time.ParseInLocation("2006-01-02 15:04", tt.to, loc)
+			if got := calendarNights(from, to); got != tt.want {
+				t.Errorf("calendarNights = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStayNights(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		from, to string // UTC
+		want     int
+	}{
+		// 15:00 CET 28.03 — 11:00 CEST 30.03
+		{"berlin spring forward", "Europe/Berlin", "2026-03-28T14:00:00Z", "2026-03-30T09:00:00Z", 2},
+		// 15:00 CEST 24.10 — 11:00 CET 26.10
+		{"berlin fall back", "Europe/Berlin", "2026-10-24T13:00:00Z", "2026-10-26T10:00:00Z", 2},
+		// 15:00 EST 07.03 — 22:00 EDT 09.03: по датам UTC вышло бы три ночи
+		{"new york spring forward", "America/New_York", "2026-03-07T20:00:00Z", "2026-03-10T02:00:00Z", 2},
+		// 15:00 EDT 31.10 — 11:00 EST 02.11
+		{"new york fall back", "America/New_York", "2026-10-31T19:00:00Z", "2026-11-02T16:00:00Z", 2},
+		// 21:00 EDT 31.10 — 01:30 EST 01.11: в UTC один день
+		{"new york overnight", "America/New_York", "2026-11-01T01:00:00Z", "2026-11-01T06:30:00Z", 1},
+		{"same local day", "Europe/Berlin", "2026-03-29T08:00:00Z", "2026-03-29T12:00:00Z", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			to, _ := time.Parse(time.RFC3339, tt.to)
+			if got := stayNights(from, to, mustZone(t, tt.zone)); got != tt.want {
+				t.Errorf("stayNights = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
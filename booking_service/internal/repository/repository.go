@@ -3,19 +3,122 @@ package repository
 import (
 	"booking_service/internal/dtos"
 	"booking_service/internal/models"
+	"time"
 )
 
 type Repository interface {
 	AddApartment(dto *dtos.ApartmentCreateDTO) (models.Apartment, error)
+	// ImportApartments проверяет и создаёт апартаменты пакетом; итог пишется в каждую строку.
+	// Ошибка возвращается, только если импорт не удалось провести вообще
+	ImportApartments(rows []dtos.ApartmentImportRow, partial, dryRun bool) error
+	ExportApartments(ownerID, status string) ([]models.Apartment, error)
 
 	UpdateApartmentLight(id string, dto *dtos.ApartmentLightUpdateDTO) error
 	UpdateApartmentHeavy(id string, dto *dtos.ApartmentHeavyUpdateDTO) error
 
-	GetApartments(filter map[string]string) (*[]models.Apartment, error) // empty, if no filter
-	GetApartment(id string) (models.Apartment, []dtos.BookingRange, error)
+	// asOf == nil — текущее состояние, иначе цена и описание на указанный момент
+	GetApartments(filter map[string]string, asOf *time.Time, page dtos.PageRequest) (*[]models.Apartment, dtos.PageInfo, error) // empty, if no filter
+	GetApartment(id string, asOf *time.Time) (models.Apartment, []dtos.BookingRange, error)
+	GetApartmentHistory(id string, from, to *time.Time) (models.Apartment, error)
 
-	CreateBooking(dto *dtos.BookingCreateDTO) (models.Booking, error)
+	GetStayRules(apID string) (models.StayRules, error)
+	UpdateStayRules(apID string, dto *dtos.StayRulesDTO) (models.StayRules, error)
+	QuoteStay(apID string, stay dtos.StayDTO, guests dtos.GuestsDTO) (models.Quote, error)
+	// CreateBooking создаёт бронь в ожидании оплаты вместе с платежом по шаблону payment (провайдер, комиссия);
+	// сумма и валюта платежа берутся из расчёта стоимости
+	CreateBooking(dto *dtos.BookingCreateDTO, payment *models.Payment) (models.Booking, error)
+	CancelBooking(id string, dto *dtos.UserActionDTO) (models.Booking, error)
 
-	GetApartmentsByOwner(id string) (*[]models.Apartment, error)
-	GetBookingsByUser(id string) (*[]models.Booking, error)
+	GetApartmentsByOwner(id string, status string, page dtos.PageRequest) (*[]models.Apartment, dtos.PageInfo, error)
+	GetBookingsByUser(id string, page dtos.PageRequest) (*[]models.Booking, dtos.PageInfo, error)
+
+	ChangeApartmentStatus(id string, dto *dtos.ApartmentStatusDTO) (models.Apartment, error)
+
+	// CheckApartmentAccess — владелец или соавтор с правом permission (models.Perm*)
+	CheckApartmentAccess(apID, userID, permission string) error
+
+	AddApartmentPhoto(ownerID string, photo *models.ApartmentPhoto) error
+	GetApartmentPhotos(apID string) ([]models.ApartmentPhoto, error)
+	ReorderApartmentPhotos(apID string, dto *dtos.PhotoOrderDTO) ([]models.ApartmentPhoto, error)
+	SetApartmentCover(apID, photoID, ownerID string) error
+	DeleteApartmentPhoto(apID, photoID, ownerID string) (models.ApartmentPhoto, error)
+
+	// GetApartmentBookings — брони апартамента для владельца и соавторов с manage_bookings
+	GetApartmentBookings(apID, userID string, page dtos.PageRequest) (*[]models.Booking, dtos.PageInfo, error)
+
+	InitiateTransfer(apID string, dto *dtos.TransferCreateDTO) (models.OwnershipTransfer, error)
+	// ResolveTransfer переводит pending-передачу в status (accepted/declined/cancelled)
+	ResolveTransfer(transferID, userID, status string) (models.OwnershipTransfer, error)
+	GetTransfersByUser(userID string) ([]models.OwnershipTransfer, error)
+
+	SetCoHost(apID, userID string, dto *dtos.CoHostDTO) (models.CoHost, error)
+	RemoveCoHost(apID, userID, ownerID string) error
+	GetCoHosts(apID, ownerID string) ([]models.CoHost, error)
+
+	// GetCalendarFeed возвращает секретную iCal-ленту апартамента, создавая её при первом обращении
+	GetCalendarFeed(apID, userID string) (models.CalendarFeed, error)
+	RotateCalendarFeed(apID, userID string) (models.CalendarFeed, error)
+	// GetCalendarByToken — апартамент ленты с предстоящими бронями и импортированными блоками
+	GetCalendarByToken(token string) (models.Apartment, []models.Booking, []models.CalendarBlock, error)
+	AddCalendarImport(apID string, dto *dtos.CalendarImportDTO) (models.CalendarImport, error)
+	GetCalendarImports(apID, userID string) ([]models.CalendarImport, error)
+	DeleteCalendarImport(apID, importID, userID string) error
+	GetCalendarConflicts(apID, userID string) ([]models.CalendarConflict, error)
+	// GetCalendarImportsToSync, ReplaceCalendarBlocks и RecordCalendarSyncError — шаги синхронизации
+	// внешних календарей; ReplaceCalendarBlocks возвращает только новые конфликты с бронями
+	GetCalendarImportsToSync(syncedBefore time.Time, limit int) ([]models.CalendarImport, error)
+	ReplaceCalendarBlocks(importID string, evs []models.CalendarEvent) ([]models.CalendarConflict, error)
+	RecordCalendarSyncError(importID, reason string) error
+
+	CreateWebhook(dto *dtos.WebhookCreateDTO) (models.WebhookSubscription, error)
+	GetWebhooksByOwner(ownerID string) ([]models.WebhookSubscription, error)
+	UpdateWebhook(id string, dto *dtos.WebhookUpdateDTO) (models.WebhookSubscription, error)
+	DeleteWebhook(id, ownerID string) error
+	GetWebhookDeliveries(id, ownerID string, page dtos.PageRequest) (*[]models.WebhookDelivery, dtos.PageInfo, error)
+	// ReplayWebhookDelivery ставит доставку в очередь заново с обнулённым счётчиком попыток
+	ReplayWebhookDelivery(id, deliveryID, ownerID string) (models.WebhookDelivery, error)
+
+	GetNotificationPreferences(userID string) (models.NotificationPreference, error)
+	UpdateNotificationPreferences(userID string, dto *dtos.NotificationPreferencesDTO) (models.NotificationPreference, error)
+	GetNotifications(userID string, q dtos.NotificationQuery) (*[]models.Notification, dtos.PageInfo, error)
+	MarkNotificationRead(id, userID string) error
+
+	// GetOwnerEventsSince — события владельца с номером публикации > after, для догона SSE-потока
+	GetOwnerEventsSince(ownerID string, after int64, limit int) ([]models.OutboxEvent, error)
+	GetLatestPublishSeq() (int64, error)
+
+	// StartInquiry создаёт (или продолжает) переписку гостя по апартаменту до бронирования
+	StartInquiry(apID string, dto *dtos.InquiryDTO) (models.Thread, models.Message, error)
+	// GetBookingThread возвращает переписку по брони, создавая её при первом обращении
+	GetBookingThread(bookingID, userID string) (models.Thread, error)
+	GetThreadsByUser(userID string, page dtos.PageRequest) (*[]models.Thread, dtos.PageInfo, error)
+	// CheckThreadParticipant — гость переписки, текущий владелец апартамента или соавтор с manage_bookings
+	CheckThreadParticipant(threadID, userID string) (models.Thread, error)
+	GetThreadMessages(threadID, userID string, page dtos.PageRequest) (*[]models.Message, []models.ThreadRead, dtos.PageInfo, error)
+	GetThreadAttachment(threadID, attachmentID, userID string) (models.MessageAttachment, error)
+	AddMessage(msg *models.Message) error
+	MarkThreadRead(threadID, userID string) error
+
+	// ApplyPaymentUpdate идемпотентно переводит платёж и его бронь в новое состояние
+	ApplyPaymentUpdate(dto *dtos.PaymentUpdateDTO) (models.Payment, error)
+	GetBookingPayment(bookingID string) (models.Payment, error)
+	// GetPaymentsToReconcile — незавершённые платежи, не менявшиеся с before
+	GetPaymentsToReconcile(before time.Time, limit int) ([]models.Payment, error)
+
+	// ReleaseHostFunds и CreatePayouts — шаги пакетной выплаты хостам
+	ReleaseHostFunds(checkedInBefore time.Time) (int, error)
+	CreatePayouts() ([]models.Payout, error)
+	GetOwnerBalance(ownerID string) ([]models.OwnerBalance, error)
+	GetOwnerPayouts(ownerID string, page dtos.PageRequest) (*[]models.Payout, dtos.PageInfo, error)
+
+	GetBillingProfile(userID string) (models.BillingProfile, error)
+	UpdateBillingProfile(userID string, dto *dtos.BillingProfileDTO) (models.BillingProfile, error)
+	// GetInvoice выставляет счёт при первом обращении, дальше возвращает тот же
+	GetInvoice(bookingID, userID string) (models.Invoice, error)
+
+	AddReview(bookingID string, dto *dtos.ReviewCreateDTO) (models.Review, error)
+	// PublishDueReviews раскрывает отзывы с истёкшим окном и возвращает их число
+	PublishDueReviews() (int, error)
+	GetApartmentReviews(apID string, page dtos.PageRequest) (*[]models.Review, dtos.PageInfo, error)
+	GetGuestReviews(userID string, page dtos.PageRequest) (*[]models.Review, dtos.PageInfo, error)
 }
@@ -2,113 +2,162 @@ package repository
 
 import (
 	"booking_service/internal/dtos"
+	"booking_service/internal/events"
+	"booking_service/internal/fees"
 	"booking_service/internal/models"
+	"booking_service/internal/money"
 	servererrors "booking_service/internal/server_errors"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repositoryWithTM struct {
-	tm *transactionManager
+	tm   *transactionManager
+	fees *fees.Schedule
 }
 
-func NewRepository(db *gorm.DB) Repository {
+func NewRepository(db *gorm.DB, schedule *fees.Schedule) Repository {
 	return &repositoryWithTM{
-		tm: &transactionManager{db},
+		tm:   &transactionManager{db},
+		fees: schedule,
 	}
 }
 
-func (r *repositoryWithTM) AddApartment(dto *dtos.ApartmentCreateDTO) (models.Apartment, error) {
+// apartmentDraft — апартамент и его первое описание, проверенные, но ещё не записанные
+type apartmentDraft struct {
+	ap   models.Apartment
+	desc *models.Description
+}
+
+// prepareApartment проверяет dto по правилам создания апартамента; общая часть AddApartment
+// и пакетного импорта. В БД ничего не пишет. Проверка адреса здесь — для понятной ошибки
+// до записи; гонку двух запросов решает уникальный индекс (см. createApartment)
+func prepareApartment(db *gorm.DB, dto *dtos.ApartmentCreateDTO) (apartmentDraft, error) {
 	var existing models.Apartment
-	err := r.tm.db.Where("address = ?", dto.Address).First(&existing).Error
+	err := db.Where("address = ?", dto.Address).First(&existing).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return models.Apartment{}, err
+		return apartmentDraft{}, err
 	}
 
 	if err == nil {
-		return models.Apartment{}, &servererrors.AlreadyExistsError{Field: "address", Value: dto.Address}
+		return apartmentDraft{}, &servererrors.AlreadyExistsError{Field: "address", Value: dto.Address}
 	}
 
-	id := uuid.New().String()
-	ap := models.Apartment{
-		ID:        id,
-		OwnerID:   dto.OwnerID,
-		Address:   dto.Address,
-		Price:     dto.Price,
-		UpdatedAt: time.Now(),
+	infoText := make([]string, 0, len(dto.Info))
+	for k, v := range dto.Info {
+		infoText = append(infoText, k, v)
 	}
 
-	// TRANSACTION [BEGIN]
-	tx, err := r.tm.begin()
+	lang, err := searchLanguage(dto.Language, append(infoText, dto.Address)...)
 	if err != nil {
-		return models.Apartment{}, err
+		return apartmentDraft{}, err
 	}
 
-	if err := tx.Create(&ap).Error; err != nil {
-		_ = r.tm.rollback(tx)
-		return models.Apartment{}, err
+	currency := money.Normalize(dto.Currency)
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	ve := &servererrors.ValidationError{}
+	validatePrice(ve, *dto.Price, currency)
+	cleaningFee := decimal.Zero
+	if dto.CleaningFee != nil {
+		cleaningFee = *dto.CleaningFee
+		validateFee(ve, "cleaning_fee", cleaningFee, currency)
+	}
+	timeZone := dto.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	} else if !validTimeZone(timeZone) {
+		ve.Add("time_zone", "unknown IANA time zone "+timeZone)
+	}
+	if len(ve.Fields) > 0 {
+		return apartmentDraft{}, ve
 	}
 
-	if len(dto.Info) > 0 {
-		ve := &servererrors.ValidationError{}
-
-		var rooms, beds *int
-		other := map[string]string{}
-
-		for k, v := range dto.Info {
-			switch k {
-			case "rooms":
-				val, err := strconv.Atoi(v)
-				if err != nil {
-					ve.Add("info.rooms", "must be an integer")
-				} else if val <= 0 {
-					ve.Add("info.rooms", "must be > 0")
-				} else {
-					rooms = &val
-				}
-			case "beds":
-				val, err := strconv.Atoi(v)
-				if err != nil {
-					ve.Add("info.beds", "must be an integer")
-				} else if val < 0 {
-					ve.Add("info.beds", "must be >= 0")
-				} else {
-					beds = &val
-				}
-			default:
-				other[k] = v
-			}
-		}
-
-		if len(ve.Fields) > 0 {
-			return models.Apartment{}, ve
-		}
-
-		descJSON, _ := json.Marshal(other)
+	id := uuid.New().String()
+	ap := models.Apartment{
+		ID:          id,
+		OwnerID:     dto.OwnerID,
+		Address:     dto.Address,
+		Price:       *dto.Price,
+		Currency:    currency,
+		CleaningFee: cleaningFee,
+		TimeZone:    timeZone,
+		Status:      models.StatusPublished,
+		SearchLang:  lang,
+		UpdatedAt:   time.Now(),
+	}
+	if dto.Status != "" {
+		ap.Status = dto.Status
+	}
+
+	if dto.Location != nil {
+		ap.Country = dto.Location.Country
+		ap.City = dto.Location.City
+		ap.Street = dto.Location.Street
+		ap.PostalCode = dto.Location.PostalCode
+		ap.Lat = dto.Location.Lat
+		ap.Lng = dto.Location.Lng
+	}
+
+	parsed, err := parseDescription(dto.Info, dto.Amenities, nil)
+	if err != nil {
+		return apartmentDraft{}, err
+	}
 
+	draft := apartmentDraft{ap: ap}
+	if len(dto.Info) > 0 || len(dto.Amenities) > 0 {
 		desc := models.Description{
 			ID:          uuid.New().String(),
 			ApartmentID: id,
-			Description: string(descJSON),
-		}
-		if rooms != nil {
-			desc.Rooms = *rooms
 		}
-		if beds != nil {
-			desc.Beds = *beds
+		parsed.apply(&desc)
+		draft.desc = &desc
+	}
+
+	return draft, nil
+}
+
+// createApartment записывает подготовленный апартамент в транзакции tx
+func (r *repositoryWithTM) createApartment(tx *gorm.DB, d *apartmentDraft) error {
+	if err := tx.Create(&d.ap).Error; err != nil {
+		if uniqueViolation(err, "idx_apartments_address") {
+			return &servererrors.AlreadyExistsError{Field: "address", Value: d.ap.Address}
 		}
+		return err
+	}
 
-		if err := tx.Create(&desc).Error; err != nil {
-			_ = r.tm.rollback(tx)
-			return models.Apartment{}, err
+	if d.desc != nil {
+		if err := tx.Create(d.desc).Error; err != nil {
+			return err
 		}
+		d.ap.Descriptions = []models.Description{*d.desc}
+	}
+
+	return r.tm.emit(tx, events.ApartmentCreated, d.ap.ID, apartmentPayload(&d.ap))
+}
+
+func (r *repositoryWithTM) AddApartment(dto *dtos.ApartmentCreateDTO) (models.Apartment, error) {
+	draft, err := prepareApartment(r.tm.db, dto)
+	if err != nil {
+		return models.Apartment{}, err
+	}
+
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Apartment{}, err
+	}
+
+	if err := r.createApartment(tx, &draft); err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Apartment{}, err
 	}
 
 	// COMMIT (TRANSACTION END)
@@ -116,8 +165,8 @@ func (r *repositoryWithTM) AddApartment(dto *dtos.ApartmentCreateDTO) (models.Ap
 		return models.Apartment{}, err
 	}
 
-	logrus.WithTime(time.Now()).Infof("Successfuly created apartment, id = %s", id)
-	return ap, nil
+	logrus.WithTime(time.Now()).Infof("Successfuly created apartment, id = %s", draft.ap.ID)
+	return draft.ap, nil
 }
 
 func (r *repositoryWithTM) UpdateApartmentLight(id string, dto *dtos.ApartmentLightUpdateDTO) error {
@@ -127,26 +176,47 @@ func (r *repositoryWithTM) UpdateApartmentLight(id string, dto *dtos.ApartmentLi
 		return err
 	}
 
-	operationTimestamp := time.Now().Add(2 * time.Second)
+	operationTimestamp := time.Now()
 
 	var ap models.Apartment
 	if err = tx.Where("id = ?", id).First(&ap).Error; err != nil {
 		_ = r.tm.rollback(tx)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("apartment with id '%s' does not exist", id)
+			return &servererrors.NotFoundError{Entity: "apartment", Key: id}
 		}
 		return err
 	}
 
-	if ap.OwnerID != dto.OwnerID {
+	if err := authorize(tx, &ap, dto.OwnerID, models.PermEditPricing); err != nil {
 		_ = r.tm.rollback(tx)
-		return &servererrors.ForbiddenAccessError{
-			UserId:       dto.OwnerID,
-			ResourceType: "apartment",
-			ResourceId:   ap.ID,
+		return err
+	}
+	// часовой пояс — часть объявления
+	if dto.TimeZone != "" && dto.TimeZone != ap.TimeZone {
+		if err := authorize(tx, &ap, dto.OwnerID, models.PermEditListing); err != nil {
+			_ = r.tm.rollback(tx)
+			return err
 		}
 	}
 
+	if ap.Status == models.StatusArchived {
+		_ = r.tm.rollback(tx)
+		return &servererrors.StateConflictError{Entity: "apartment", Key: id, Reason: "archived apartment cannot be updated"}
+	}
+
+	ve := &servererrors.ValidationError{}
+	validatePrice(ve, *dto.Price, ap.Currency)
+	if dto.CleaningFee != nil {
+		validateFee(ve, "cleaning_fee", *dto.CleaningFee, ap.Currency)
+	}
+	if dto.TimeZone != "" && !validTimeZone(dto.TimeZone) {
+		ve.Add("time_zone", "unknown IANA time zone "+dto.TimeZone)
+	}
+	if len(ve.Fields) > 0 {
+		_ = r.tm.rollback(tx)
+		return ve
+	}
+
 	scd4 := models.ApartmentSCD4{
 		ID:           uuid.New().String(),
 		ApartmentID:  ap.ID,
@@ -163,6 +233,12 @@ func (r *repositoryWithTM) UpdateApartmentLight(id string, dto *dtos.ApartmentLi
 	if dto.Price != nil {
 		ap.Price = *dto.Price
 	}
+	if dto.CleaningFee != nil {
+		ap.CleaningFee = *dto.CleaningFee
+	}
+	if dto.TimeZone != "" {
+		ap.TimeZone = dto.TimeZone
+	}
 
 	ap.UpdatedAt = operationTimestamp
 
@@ -171,6 +247,11 @@ func (r *repositoryWithTM) UpdateApartmentLight(id string, dto *dtos.ApartmentLi
 		return err
 	}
 
+	if err := r.tm.emit(tx, events.ApartmentUpdated, ap.ID, apartmentPayload(&ap)); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
 	// COMMIT (TRANSACTION END)
 	if err := r.tm.commit(tx); err != nil {
 		return err
@@ -187,10 +268,10 @@ func (r *repositoryWithTM) UpdateApartmentHeavy(id string, dto *dtos.ApartmentHe
 		return err
 	}
 
-	operationTimestamp := time.Now().Add(2 * time.Second)
+	operationTimestamp := time.Now()
 
 	var ap models.Apartment
-	if err = tx.Preload("Descriptions", "valid_to = '9999-12-31 23:59:00'").Where("id = ?", id).First(&ap).Error; err != nil {
+	if err = tx.Preload("Descriptions", descriptionsAt(nil)).Where("id = ?", id).First(&ap).Error; err != nil {
 		_ = r.tm.rollback(tx)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return &servererrors.NotFoundError{Entity: "apartment", Key: id}
@@ -198,15 +279,36 @@ func (r *repositoryWithTM) UpdateApartmentHeavy(id string, dto *dtos.ApartmentHe
 		return err
 	}
 
-	if ap.OwnerID != dto.OwnerID {
+	if err := authorize(tx, &ap, dto.OwnerID, models.PermEditListing); err != nil {
 		_ = r.tm.rollback(tx)
-		return &servererrors.ForbiddenAccessError{
-			UserId:       dto.OwnerID,
-			ResourceType: "apartment",
-			ResourceId:   ap.ID,
+		return err
+	}
+	// смена цены или уборки требует отдельного права
+	if !dto.Price.Equal(ap.Price) || (dto.CleaningFee != nil && !dto.CleaningFee.Equal(ap.CleaningFee)) {
+		if err := authorize(tx, &ap, dto.OwnerID, models.PermEditPricing); err != nil {
+			_ = r.tm.rollback(tx)
+			return err
 		}
 	}
 
+	if ap.Status == models.StatusArchived {
+		_ = r.tm.rollback(tx)
+		return &servererrors.StateConflictError{Entity: "apartment", Key: id, Reason: "archived apartment cannot be updated"}
+	}
+
+	ve := &servererrors.ValidationError{}
+	validatePrice(ve, *dto.Price, ap.Currency)
+	if dto.CleaningFee != nil {
+		validateFee(ve, "cleaning_fee", *dto.CleaningFee, ap.Currency)
+	}
+	if dto.TimeZone != "" && !validTimeZone(dto.TimeZone) {
+		ve.Add("time_zone", "unknown IANA time zone "+dto.TimeZone)
+	}
+	if len(ve.Fields) > 0 {
+		_ = r.tm.rollback(tx)
+		return ve
+	}
+
 	scd4 := models.ApartmentSCD4{
 		ID:           uuid.New().String(),
 		ApartmentID:  ap.ID,
@@ -223,6 +325,12 @@ func (r *repositoryWithTM) UpdateApartmentHeavy(id string, dto *dtos.ApartmentHe
 	if dto.Price != nil {
 		ap.Price = *dto.Price
 	}
+	if dto.CleaningFee != nil {
+		ap.CleaningFee = *dto.CleaningFee
+	}
+	if dto.TimeZone != "" {
+		ap.TimeZone = dto.TimeZone
+	}
 
 	ap.UpdatedAt = operationTimestamp
 
@@ -231,68 +339,49 @@ func (r *repositoryWithTM) UpdateApartmentHeavy(id string, dto *dtos.ApartmentHe
 		return err
 	}
 
-	if len(dto.Info) > 0 {
-		ve := &servererrors.ValidationError{}
-
-		var rooms, beds *int
-		other := map[string]string{}
-
-		for k, v := range dto.Info {
-			switch k {
-			case "rooms":
-				val, err := strconv.Atoi(v)
-				if err != nil {
-					ve.Add("info.rooms", "must be an integer")
-				} else if val <= 0 {
-					ve.Add("info.rooms", "must be > 0")
-				} else {
-					rooms = &val
-				}
-			case "beds":
-				val, err := strconv.Atoi(v)
-				if err != nil {
-					ve.Add("info.beds", "must be an integer")
-				} else if val < 0 {
-					ve.Add("info.beds", "must be >= 0")
-				} else {
-					beds = &val
-				}
-			default:
-				other[k] = v
-			}
-		}
+	newDesc := models.Description{
+		ID:          uuid.New().String(),
+		ApartmentID: id,
+		ValidFrom:   operationTimestamp,
+	}
 
-		if len(ve.Fields) > 0 {
-			return ve
-		}
+	var oldDesc *models.Description
+	var base map[string]any
+	if len(ap.Descriptions) > 0 {
+		oldDesc = &ap.Descriptions[0]
+		base = oldDesc.AmenityMap()
+	}
 
-		descJSON, _ := json.Marshal(other)
+	parsed, err := parseDescription(dto.Info, dto.Amenities, base)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+	parsed.apply(&newDesc)
 
-		newDesc := models.Description{
-			ID:          uuid.New().String(),
-			ApartmentID: id,
-			Description: string(descJSON),
-			ValidFrom:   operationTimestamp,
-		}
-		if rooms != nil {
-			newDesc.Rooms = *rooms
-		}
-		if beds != nil {
-			newDesc.Beds = *beds
-		}
+	// info не передан — текст описания, комнаты и кровати переносятся из текущей версии
+	if dto.Info == nil && oldDesc != nil {
+		newDesc.Description = oldDesc.Description
+		newDesc.Rooms = oldDesc.Rooms
+		newDesc.Beds = oldDesc.Beds
+	}
 
-		oldDesc := ap.Descriptions[0]
+	if oldDesc != nil {
 		oldDesc.ValidTo = operationTimestamp
-
 		if err := tx.Save(oldDesc).Error; err != nil {
 			_ = r.tm.rollback(tx)
 			return err
 		}
+	}
 
-		if err := tx.Create(&newDesc).Error; err != nil {
-			_ = r.tm.rollback(tx)
-			return err
-		}
+	if err := tx.Create(&newDesc).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return err
+	}
+
+	if err := r.tm.emit(tx, events.ApartmentUpdated, ap.ID, apartmentPayload(&ap)); err != nil {
+		_ = r.tm.rollback(tx)
+		return err
 	}
 
 	// COMMIT (TRANSACTION END)
@@ -304,40 +393,127 @@ func (r *repositoryWithTM) UpdateApartmentHeavy(id string, dto *dtos.ApartmentHe
 	return nil
 }
 
-func (r *repositoryWithTM) GetApartments(filter map[string]string) (*[]models.Apartment, error) {
+func (r *repositoryWithTM) GetApartments(filter map[string]string, asOf *time.Time, page dtos.PageRequest) (*[]models.Apartment, dtos.PageInfo, error) {
 	var apartments []models.Apartment
-	db := r.tm.db.Model(&models.Apartment{}).Distinct("apartments.*")
+	db := r.tm.db.Model(&models.Apartment{}).Distinct("apartments.*").
+		Where("apartments.status = ?", models.StatusPublished)
+
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
 
 	if city, ok := filter["city"]; ok && city != "" {
-		db = db.Where("split_part(address, ',', 1) ILIKE ?", "%"+city+"%")
+		db = db.Where("apartments.city ILIKE ?", "%"+city+"%")
+	}
+
+	db, err = applyGeoFilter(db, filter)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	if asOf != nil {
+		db = existedAt(db, *asOf)
 	}
 
 	roomsFilter, hasRooms := filter["rooms"]
 	bedsFilter, hasBeds := filter["beds"]
+	amenitiesFilter, hasAmenities := filter["amenities"]
+	guestsFilter, hasGuests := filter["guests"]
 
-	if hasRooms || hasBeds {
-		db = db.Joins("JOIN description d ON d.ap_id = apartments.id AND d.valid_to = '9999-12-31 23:59:00'")
+	if hasRooms || hasBeds || hasAmenities || hasGuests {
+		db = descriptionJoinAt(db, asOf)
 		if hasRooms {
 			db = db.Where("d.rooms = ?", roomsFilter)
 		}
 		if hasBeds {
 			db = db.Where("d.beds = ?", bedsFilter)
 		}
+		if hasAmenities {
+			required, err := parseAmenityFilter(amenitiesFilter)
+			if err != nil {
+				return nil, dtos.PageInfo{}, err
+			}
+			db = db.Where("d.amenities @> ?::jsonb", required)
+		}
+		if hasGuests {
+			// вместимость как в models.Description.MaxGuests; неизвестная не ограничивает
+			db = db.Where("COALESCE((d.amenities->>'max_guests')::int, CASE WHEN d.beds > 0 THEN d.beds * 2 END, ?::int) >= ?::int",
+				guestsFilter, guestsFilter)
+		}
+	}
+
+	q, hasQuery := filter["q"]
+	if hasQuery && asOf != nil {
+		return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "q cannot be combined with as_of"}
+	}
+	sort, hasSort := filter["sort"]
+	if hasSort && sort != "rating" {
+		return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "sort must be 'rating'"}
+	}
+	if hasSort && hasQuery {
+		return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "sort cannot be combined with q"}
+	}
+
+	if hasQuery {
+		db, err = applyTextSearch(db, q, cursor)
+		if err != nil {
+			return nil, dtos.PageInfo{}, err
+		}
+	} else if hasSort {
+		db, err = applyRatingSort(db, cursor)
+		if err != nil {
+			return nil, dtos.PageInfo{}, err
+		}
+	} else {
+		if cursor != nil {
+			db = db.Where("apartments.id > ?", cursor.ID)
+		}
+		db = db.Order("apartments.id")
 	}
 
-	if err := db.Find(&apartments).Error; err != nil {
-		return nil, err
+	limit := pageLimit(page.Limit)
+	if err := db.Limit(limit + 1).Find(&apartments).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
 	}
 
-	return &apartments, nil
+	if asOf != nil {
+		if err := applyPricesAt(r.tm.db, apartments, *asOf); err != nil {
+			return nil, dtos.PageInfo{}, err
+		}
+	}
+
+	info := dtos.PageInfo{}
+	if len(apartments) > limit {
+		apartments = apartments[:limit]
+		next := pageCursor{ID: apartments[limit-1].ID}
+
+		if hasQuery {
+			var rank float32
+			if err := r.tm.db.Model(&models.Apartment{}).
+				Select(searchRankExpr, q).
+				Where("id = ?", next.ID).
+				Scan(&rank).Error; err != nil {
+				return nil, dtos.PageInfo{}, err
+			}
+			next.Key = rankCursorKey(rank)
+		} else if hasSort {
+			next.Key = ratingCursorKey(&apartments[limit-1])
+		}
+
+		info.NextCursor = encodeCursor(next)
+	}
+
+	return &apartments, info, nil
 }
 
-func (r *repositoryWithTM) GetApartment(id string) (models.Apartment, []dtos.BookingRange, error) {
+func (r *repositoryWithTM) GetApartment(id string, asOf *time.Time) (models.Apartment, []dtos.BookingRange, error) {
 	var ap models.Apartment
 	var bookings []dtos.BookingRange
 
 	err := r.tm.db.
-		Preload("Descriptions", "valid_to = '9999-12-31 23:59:00'").
+		Preload("Descriptions", descriptionsAt(asOf)).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
 		Where("id = ?", id).
 		First(&ap).Error
 	if err != nil {
@@ -347,38 +523,93 @@ func (r *repositoryWithTM) GetApartment(id string) (models.Apartment, []dtos.Boo
 		return models.Apartment{}, nil, err
 	}
 
+	if !ap.IsVisible() {
+		return models.Apartment{}, nil, &servererrors.NotFoundError{Key: id, Entity: "apartment"}
+	}
+
+	if asOf != nil {
+		if err := apartmentAt(r.tm.db, &ap, *asOf); err != nil {
+			return models.Apartment{}, nil, err
+		}
+	}
+
 	// подгружаем активные брони (у которых окончание не в прошлом)
 	if err := r.tm.db.
 		Model(&models.Booking{}).
 		Select("time_from AS from, time_to AS to").
 		Where("ap_id = ?", id).
 		Where("time_to > now()").
+		Scopes(activeBookings).
 		Scan(&bookings).Error; err != nil {
 		return ap, nil, err
 	}
 
+	// и периоды, занятые во внешних календарях
+	var blocked []dtos.BookingRange
+	if err := r.tm.db.
+		Model(&models.CalendarBlock{}).
+		Select("time_from AS from, time_to AS to").
+		Where("ap_id = ?", id).
+		Where("time_to > now()").
+		Scan(&blocked).Error; err != nil {
+		return ap, nil, err
+	}
+	bookings = append(bookings, blocked...)
+
 	return ap, bookings, nil
 }
 
-func (r *repositoryWithTM) CreateBooking(dto *dtos.BookingCreateDTO) (models.Booking, error) {
+func (r *repositoryWithTM) CreateBooking(dto *dtos.BookingCreateDTO, payment *models.Payment) (models.Booking, error) {
 	tx, err := r.tm.begin()
 	if err != nil {
 		return models.Booking{}, err
 	}
 
+	// FOR SHARE: смена статуса (архивация) ждёт окончания этой транзакции
 	var ap models.Apartment
-	if err := tx.Where("id = ?", dto.ApartmentID).First(&ap).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("id = ?", dto.ApartmentID).First(&ap).Error; err != nil {
 		_ = r.tm.rollback(tx)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.Booking{}, fmt.Errorf("apartment with id '%s' does not exist", dto.ApartmentID)
+			return models.Booking{}, &servererrors.NotFoundError{Entity: "apartment", Key: dto.ApartmentID}
 		}
 		return models.Booking{}, err
 	}
 
+	if !ap.IsVisible() {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, &servererrors.StateConflictError{Entity: "apartment", Key: ap.ID, Reason: "apartment is not open for booking"}
+	}
+
+	from, to, err := stayWindow(tx, &ap, dto.StayDTO)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+	guests := guestsFrom(dto.GuestsDTO)
+	if err := checkOccupancy(tx, ap.ID, guests); err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+
 	var conflictCount int64
 	if err := tx.Model(&models.Booking{}).
 		Where("ap_id = ?", dto.ApartmentID).
-		Where("time_from < ? AND time_to > ?", dto.TimeTo, dto.TimeFrom).
+		Where("time_from < ? AND time_to > ?", to, from).
+		Scopes(activeBookings).
+		Count(&conflictCount).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+
+	if conflictCount > 0 {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, &servererrors.OverlapError{ApId: ap.ID}
+	}
+
+	// даты, занятые во внешних календарях
+	if err := tx.Model(&models.CalendarBlock{}).
+		Where("ap_id = ?", dto.ApartmentID).
+		Where("time_from < ? AND time_to > ?", to, from).
 		Count(&conflictCount).Error; err != nil {
 		_ = r.tm.rollback(tx)
 		return models.Booking{}, err
@@ -393,8 +624,12 @@ func (r *repositoryWithTM) CreateBooking(dto *dtos.BookingCreateDTO) (models.Boo
 		ID:          uuid.New().String(),
 		UserID:      dto.UserID,
 		ApartmentID: dto.ApartmentID,
-		TimeFrom:    dto.TimeFrom,
-		TimeTo:      dto.TimeTo,
+		TimeFrom:    from,
+		TimeTo:      to,
+		CheckIn:     localDate(from, ap.Location()),
+		CheckOut:    localDate(to, ap.Location()),
+		Status:      models.BookingPendingPayment,
+		Guests:      guests,
 	}
 
 	if err := tx.Create(&booking).Error; err != nil {
@@ -402,43 +637,172 @@ func (r *repositoryWithTM) CreateBooking(dto *dtos.BookingCreateDTO) (models.Boo
 		return models.Booking{}, err
 	}
 
+	quote, err := r.quoteStay(&ap, booking.TimeFrom, booking.TimeTo, guests)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+	for i := range quote.Lines {
+		quote.Lines[i].BookingID = booking.ID
+	}
+	if err := tx.Create(&quote.Lines).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+	booking.LineItems = quote.Lines
+
+	now := time.Now()
+	payment.ID = uuid.New().String()
+	payment.BookingID = booking.ID
+	payment.Amount = money.ToMinor(quote.Total, quote.Currency)
+	payment.Currency = quote.Currency
+	payment.Status = models.PaymentPending
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+	if err := tx.Create(payment).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+
+	if err := r.tm.emit(tx, events.BookingCreated, booking.ID, bookingPayload(&booking, ap.OwnerID)); err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Booking{}, err
+	}
+
 	if err := r.tm.commit(tx); err != nil {
 		return models.Booking{}, err
 	}
 
 	booking.Apartment = ap
+	booking.Payment = payment
 
 	return booking, nil
 }
 
-func (r *repositoryWithTM) GetApartmentsByOwner(id string) (*[]models.Apartment, error) {
+func (r *repositoryWithTM) GetApartmentsByOwner(id string, status string, page dtos.PageRequest) (*[]models.Apartment, dtos.PageInfo, error) {
 	var apartments []models.Apartment
 	operationTimestamp := time.Now()
 
-	err := r.tm.db.
-		Preload("Descriptions", "valid_to = '9999-12-31 23:59:00'").
-		Preload("Bookings", "time_to >= ?", operationTimestamp).
-		Where("owner_id = ?", id).
-		Find(&apartments).Error
-
+	cursor, err := decodeCursor(page.Cursor)
 	if err != nil {
-		return nil, err
+		return nil, dtos.PageInfo{}, err
+	}
+
+	// без явного статуса архивные объявления не показываются
+	byStatus := func(db *gorm.DB) *gorm.DB {
+		if status == "" {
+			return db.Where("status <> ?", models.StatusArchived)
+		}
+		return db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Apartment{}).Where("owner_id = ?", id).Scopes(byStatus).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Scopes(byStatus).
+		Preload("Descriptions", descriptionsAt(nil)).
+		Preload("Bookings", func(db *gorm.DB) *gorm.DB {
+			return db.Where("time_to >= ?", operationTimestamp).Scopes(activeBookings)
+		}).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("owner_id = ?", id)
+
+	if cursor != nil {
+		db = db.Where("id > ?", cursor.ID)
 	}
 
-	return &apartments, nil
+	limit := pageLimit(page.Limit)
+	if err := db.Order("id").Limit(limit + 1).Find(&apartments).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(apartments) > limit {
+		apartments = apartments[:limit]
+		info.NextCursor = encodeCursor(pageCursor{ID: apartments[limit-1].ID})
+	}
+
+	return &apartments, info, nil
 }
 
-func (r *repositoryWithTM) GetBookingsByUser(id string) (*[]models.Booking, error) {
+func (r *repositoryWithTM) GetBookingsByUser(id string, page dtos.PageRequest) (*[]models.Booking, dtos.PageInfo, error) {
 	var bookings []models.Booking
 
-	err := r.tm.db.
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Booking{}).Where("user_id = ?", id).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.
 		Preload("Apartment").
-		Where("user_id = ?", id).
-		Find(&bookings).Error
+		Where("user_id = ?", id)
+
+	if cursor != nil {
+		from, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(time_from, booking_id) > (?, ?)", from, cursor.ID)
+	}
+
+	limit := pageLimit(page.Limit)
+	if err := db.Order("time_from").Order("booking_id").Limit(limit + 1).Find(&bookings).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(bookings) > limit {
+		bookings = bookings[:limit]
+		last := bookings[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.TimeFrom.Format(time.RFC3339Nano), ID: last.ID})
+	}
 
+	return &bookings, info, nil
+}
+
+func (r *repositoryWithTM) GetApartmentBookings(apID, userID string, page dtos.PageRequest) (*[]models.Booking, dtos.PageInfo, error) {
+	if err := r.CheckApartmentAccess(apID, userID, models.PermManageBookings); err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	cursor, err := decodeCursor(page.Cursor)
 	if err != nil {
-		return nil, err
+		return nil, dtos.PageInfo{}, err
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Booking{}).Where("ap_id = ?", apID).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Where("ap_id = ?", apID)
+	if cursor != nil {
+		from, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(time_from, booking_id) > (?, ?)", from, cursor.ID)
+	}
+
+	var bookings []models.Booking
+	limit := pageLimit(page.Limit)
+	if err := db.Order("time_from").Order("booking_id").Limit(limit + 1).Find(&bookings).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(bookings) > limit {
+		bookings = bookings[:limit]
+		last := bookings[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.TimeFrom.Format(time.RFC3339Nano), ID: last.ID})
 	}
 
-	return &bookings, nil
+	return &bookings, info, nil
 }
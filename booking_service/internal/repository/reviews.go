@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const ratingSortExpr = "COALESCE(apartments.rating_avg, 0)"
+
+// applyRatingSort — сначала лучшие по средней оценке, без оценок в конце
+func applyRatingSort(db *gorm.DB, cursor *pageCursor) (*gorm.DB, error) {
+	if cursor != nil {
+		rating, err := strconv.ParseFloat(cursor.Key, 64)
+		if err != nil {
+			return nil, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("("+ratingSortExpr+" < ?::numeric OR ("+ratingSortExpr+" = ?::numeric AND apartments.id > ?))",
+			rating, rating, cursor.ID)
+	}
+
+	return db.Order(ratingSortExpr + " DESC").Order("apartments.id"), nil
+}
+
+func ratingCursorKey(ap *models.Apartment) string {
+	if ap.RatingAvg == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*ap.RatingAvg, 'f', 2, 64)
+}
+
+// refreshApartmentRating пересчитывает агрегаты по опубликованным отзывам гостей
+func refreshApartmentRating(db *gorm.DB, apID string) error {
+	return db.Exec(`UPDATE apartments SET
+		rating_avg = (SELECT round(avg(overall)::numeric, 2) FROM reviews
+			WHERE ap_id = @ap AND kind = @kind AND published_at IS NOT NULL),
+		rating_count = (SELECT count(*) FROM reviews
+			WHERE ap_id = @ap AND kind = @kind AND published_at IS NOT NULL)
+		WHERE id = @ap`,
+		map[string]any{"ap": apID, "kind": models.ReviewOfApartment}).Error
+}
+
+// AddReview принимает отзыв по завершённой брони. Отзывы скрыты (double-blind), пока
+// вторая сторона не оставит свой или не закроется окно ReviewWindow после выезда
+func (r *repositoryWithTM) AddReview(bookingID string, dto *dtos.ReviewCreateDTO) (models.Review, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Review{}, err
+	}
+
+	var b models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+		Joins("Apartment").
+		Where("bookings.booking_id = ?", bookingID).
+		First(&b).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Review{}, &servererrors.NotFoundError{Entity: "booking", Key: bookingID}
+		}
+		return models.Review{}, err
+	}
+
+	review := models.Review{
+		ID:          uuid.New().String(),
+		BookingID:   b.ID,
+		ApartmentID: b.ApartmentID,
+		AuthorID:    dto.UserID,
+		Overall:     dto.Overall,
+		Comment:     dto.Comment,
+		CreatedAt:   time.Now(),
+	}
+
+	switch dto.UserID {
+	case b.UserID:
+		review.Kind = models.ReviewOfApartment
+		review.SubjectID = b.ApartmentID
+	case b.Apartment.OwnerID:
+		review.Kind = models.ReviewOfGuest
+		review.SubjectID = b.UserID
+	default:
+		_ = r.tm.rollback(tx)
+		return models.Review{}, &servererrors.ForbiddenAccessError{UserId: dto.UserID, ResourceType: "booking", ResourceId: bookingID}
+	}
+
+	now := time.Now()
+	if b.Status != models.BookingConfirmed || b.TimeTo.After(now) {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, &servererrors.StateConflictError{Entity: "booking", Key: bookingID, Reason: "only completed stays can be reviewed"}
+	}
+	if now.After(b.TimeTo.Add(models.ReviewWindow)) {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, &servererrors.StateConflictError{Entity: "booking", Key: bookingID, Reason: "review window has closed"}
+	}
+
+	ve := &servererrors.ValidationError{}
+	if review.Kind == models.ReviewOfApartment {
+		for _, cat := range models.ReviewCategories {
+			score, ok := dto.Categories[cat]
+			if !ok {
+				ve.Add("categories."+cat, "is required")
+			} else if score < 1 || score > 5 {
+				ve.Add("categories."+cat, "must be between 1 and 5")
+			}
+		}
+		for cat := range dto.Categories {
+			if !models.IsReviewCategory(cat) {
+				ve.Add("categories."+cat, "unknown category")
+			}
+		}
+	} else if len(dto.Categories) > 0 {
+		ve.Add("categories", "only guests rate categories")
+	}
+	if len(ve.Fields) > 0 {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, ve
+	}
+	review.SetCategories(dto.Categories)
+
+	var exists int64
+	if err := tx.Model(&models.Review{}).Where("booking_id = ? AND kind = ?", b.ID, review.Kind).Count(&exists).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, err
+	}
+	if exists > 0 {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, &servererrors.AlreadyExistsError{Field: "review.booking_id", Value: bookingID}
+	}
+
+	if err := tx.Create(&review).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, err
+	}
+
+	// обе стороны высказались — раскрываем оба отзыва сразу
+	res := tx.Model(&models.Review{}).
+		Where("booking_id = ? AND published_at IS NULL", b.ID).
+		Where("(SELECT count(*) FROM reviews r2 WHERE r2.booking_id = ?) = 2", b.ID).
+		Update("published_at", now)
+	if res.Error != nil {
+		_ = r.tm.rollback(tx)
+		return models.Review{}, res.Error
+	}
+	if res.RowsAffected > 0 {
+		review.PublishedAt = &now
+		if err := refreshApartmentRating(tx, b.ApartmentID); err != nil {
+			_ = r.tm.rollback(tx)
+			return models.Review{}, err
+		}
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.Review{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Review %s for booking %s added", review.ID, bookingID)
+	return review, nil
+}
+
+// PublishDueReviews раскрывает отзывы, у которых закончилось окно ожидания второй стороны
+func (r *repositoryWithTM) PublishDueReviews() (int, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var due []models.Review
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}, Options: "SKIP LOCKED"}).
+		Joins("JOIN bookings b ON b.booking_id = reviews.booking_id").
+		Where("reviews.published_at IS NULL AND b.time_to < ?", time.Now().Add(-models.ReviewWindow)).
+		Find(&due).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return 0, err
+	}
+	if len(due) == 0 {
+		_ = r.tm.rollback(tx)
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(due))
+	apartments := map[string]bool{}
+	for _, rv := range due {
+		ids = append(ids, rv.ID)
+		apartments[rv.ApartmentID] = true
+	}
+
+	if err := tx.Model(&models.Review{}).Where("review_id IN ?", ids).Update("published_at", time.Now()).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return 0, err
+	}
+	for apID := range apartments {
+		if err := refreshApartmentRating(tx, apID); err != nil {
+			_ = r.tm.rollback(tx)
+			return 0, err
+		}
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return 0, err
+	}
+	return len(due), nil
+}
+
+func (r *repositoryWithTM) GetApartmentReviews(apID string, page dtos.PageRequest) (*[]models.Review, dtos.PageInfo, error) {
+	return r.publishedReviews(func(db *gorm.DB) *gorm.DB {
+		return db.Where("ap_id = ? AND kind = ?", apID, models.ReviewOfApartment)
+	}, page)
+}
+
+func (r *repositoryWithTM) GetGuestReviews(userID string, page dtos.PageRequest) (*[]models.Review, dtos.PageInfo, error) {
+	return r.publishedReviews(func(db *gorm.DB) *gorm.DB {
+		return db.Where("subject_id = ? AND kind = ?", userID, models.ReviewOfGuest)
+	}, page)
+}
+
+// publishedReviews — только раскрытые отзывы, новые первыми
+func (r *repositoryWithTM) publishedReviews(scope func(*gorm.DB) *gorm.DB, page dtos.PageRequest) (*[]models.Review, dtos.PageInfo, error) {
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	published := func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(scope).Where("published_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.Review{}).Scopes(published).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Scopes(published)
+	if cursor != nil {
+		at, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(published_at, review_id) < (?, ?)", at, cursor.ID)
+	}
+
+	var reviews []models.Review
+	limit := pageLimit(page.Limit)
+	if err := db.Order("published_at DESC").Order("review_id DESC").Limit(limit + 1).Find(&reviews).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+		last := reviews[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.PublishedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	return &reviews, info, nil
+}
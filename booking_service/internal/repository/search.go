@@ -0,0 +1,87 @@
+package repository
+
+import (
+	servererrors "booking_service/internal/server_errors"
+	"strconv"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// searchConfigs — коды языков ISO 639-1 и соответствующие конфигурации полнотекстового поиска Postgres
+var searchConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+const searchQueryExpr = "websearch_to_tsquery(apartments.search_lang::regconfig, ?)"
+const searchRankExpr = "ts_rank(apartments.search_vector, " + searchQueryExpr + ")"
+
+// searchLanguage выбирает конфигурацию по явному коду языка, иначе угадывает по тексту
+func searchLanguage(code string, texts ...string) (string, error) {
+	if code != "" {
+		cfg, ok := searchConfigs[code]
+		if !ok {
+			ve := &servererrors.ValidationError{}
+			ve.Add("language", "unsupported language code")
+			return "", ve
+		}
+		return cfg, nil
+	}
+
+	for _, text := range texts {
+		for _, r := range text {
+			if unicode.Is(unicode.Cyrillic, r) {
+				return "russian", nil
+			}
+		}
+	}
+
+	return "english", nil
+}
+
+// LanguageCode — код языка для конфигурации поиска, как его принимает поле language
+func LanguageCode(searchConfig string) string {
+	for code, cfg := range searchConfigs {
+		if cfg == searchConfig {
+			return code
+		}
+	}
+	return ""
+}
+
+// applyTextSearch ограничивает выборку совпадениями с q и сортирует по релевантности.
+// Курсор хранит ранг последней записи, чтобы страницы не пересекались.
+func applyTextSearch(db *gorm.DB, q string, cursor *pageCursor) (*gorm.DB, error) {
+	db = db.
+		Select("apartments.*, "+searchRankExpr+" AS rank", q). // DISTINCT сохраняется из GetApartments
+		Where("apartments.search_vector @@ "+searchQueryExpr, q)
+
+	if cursor != nil {
+		rank, err := strconv.ParseFloat(cursor.Key, 32)
+		if err != nil {
+			return nil, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("("+searchRankExpr+" < ?::real OR ("+searchRankExpr+" = ?::real AND apartments.id > ?))",
+			q, rank, q, rank, cursor.ID)
+	}
+
+	return db.Order("rank DESC").Order("apartments.id"), nil
+}
+
+func rankCursorKey(rank float32) string {
+	return strconv.FormatFloat(float64(rank), 'g', -1, 32)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChangeApartmentStatus меняет статус объявления. Архивация — мягкое удаление: строки
+// не удаляются (иначе каскад унёс бы историю бронирований) и запрещена при будущих бронях.
+// Из архива можно вернуться только в черновик.
+func (r *repositoryWithTM) ChangeApartmentStatus(id string, dto *dtos.ApartmentStatusDTO) (models.Apartment, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.Apartment{}, err
+	}
+
+	ap, err := lockApartmentFor(tx, id, dto.OwnerID, models.PermOwner)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Apartment{}, err
+	}
+
+	if ap.Status == dto.Status {
+		_ = r.tm.rollback(tx)
+		return ap, nil
+	}
+
+	if ap.Status == models.StatusArchived && dto.Status != models.StatusDraft {
+		_ = r.tm.rollback(tx)
+		return models.Apartment{}, &servererrors.StateConflictError{
+			Entity: "apartment", Key: id, Reason: "archived apartment can only be restored as draft",
+		}
+	}
+
+	now := time.Now()
+
+	if dto.Status == models.StatusArchived {
+		var upcoming int64
+		if err := tx.Model(&models.Booking{}).
+			Where("ap_id = ? AND time_to > ?", id, now).
+			Scopes(activeBookings).
+			Count(&upcoming).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return models.Apartment{}, err
+		}
+
+		if upcoming > 0 {
+			_ = r.tm.rollback(tx)
+			return models.Apartment{}, &servererrors.StateConflictError{
+				Entity: "apartment", Key: id, Reason: "apartment has current or future bookings",
+			}
+		}
+
+		ap.ArchivedAt = &now
+	} else {
+		ap.ArchivedAt = nil
+	}
+
+	ap.Status = dto.Status
+	// UpdateColumns не трогает updated_at: это начало действия текущей цены (apartments_scd4)
+	if err := tx.Model(&ap).Select("status", "archived_at").UpdateColumns(&ap).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Apartment{}, err
+	}
+
+	if err := r.tm.emit(tx, events.ApartmentStatusChanged, ap.ID, apartmentPayload(&ap)); err != nil {
+		_ = r.tm.rollback(tx)
+		return models.Apartment{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.Apartment{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Apartment %s is now %s", id, ap.Status)
+	return ap, nil
+}
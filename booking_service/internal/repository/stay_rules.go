@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func stayRulesFor(db *gorm.DB, apID string) (models.StayRules, error) {
+	rules := models.DefaultStayRules(apID)
+	err := db.Where("ap_id = ?", apID).First(&rules).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.StayRules{}, err
+	}
+	return rules, nil
+}
+
+// checkStayRules добавляет в ve все нарушенные правила, а не только первое.
+// from и to должны быть в часовом поясе апартамента: по нему считаются дни недели, часы и ночи
+func checkStayRules(ve *servererrors.ValidationError, rules *models.StayRules, from, to, now time.Time) {
+	if from.Before(now) {
+		ve.Add("time_from", "check-in must be in the future")
+	} else if notice := time.Duration(rules.MinNoticeHours) * time.Hour; from.Before(now.Add(notice)) {
+		ve.Add("min_notice_hours", fmt.Sprintf("bookings must be made at least %d hours before check-in", rules.MinNoticeHours))
+	}
+	if rules.MaxHorizonDays > 0 && from.After(now.AddDate(0, 0, rules.MaxHorizonDays)) {
+		ve.Add("max_horizon_days", fmt.Sprintf("check-in must be within %d days from now", rules.MaxHorizonDays))
+	}
+
+	nights := calendarNights(from, to)
+	if nights < rules.MinNights {
+		ve.Add("min_nights", fmt.Sprintf("stay must be at least %d nights, requested %d", rules.MinNights, nights))
+	}
+	if rules.MaxNights > 0 && nights > rules.MaxNights {
+		ve.Add("max_nights", fmt.Sprintf("stay must be at most %d nights, requested %d", rules.MaxNights, nights))
+	}
+
+	if !models.AllowsWeekday(rules.CheckInDays, from.Weekday()) {
+		ve.Add("check_in_days", "check-in is allowed only on "+strings.Join(models.WeekdayNames(rules.CheckInDays), ", "))
+	}
+	if !models.AllowsWeekday(rules.CheckOutDays, to.Weekday()) {
+		ve.Add("check_out_days", "check-out is allowed only on "+strings.Join(models.WeekdayNames(rules.CheckOutDays), ", "))
+	}
+
+	if rules.CheckInTime != "" && !atClock(from, rules.CheckInTime) {
+		ve.Add("check_in_time", "check-in must be at "+rules.CheckInTime)
+	}
+	if rules.CheckOutTime != "" && !atClock(to, rules.CheckOutTime) {
+		ve.Add("check_out_time", "check-out must be at "+rules.CheckOutTime)
+	}
+}
+
+// atClock — t приходится на время clock своего дня. В день перехода на летнее время
+// пропущенный час сдвинут так же, как в parseLocal
+func atClock(t time.Time, clock string) bool {
+	if t.Format("15:04") == clock {
+		return true
+	}
+	want, err := parseLocal(t.Format("2006-01-02")+" "+clock, t.Location())
+	return err == nil && t.Equal(want)
+}
+
+const stayLayout = "2006-01-02 15:04"
+
+// parseLocal — местное время в поясе loc. Для часа, пропущенного при переходе на летнее время,
+// берётся момент после перехода (02:30 → 03:30): сам time.Date в разных поясах сдвигает
+// такое время то вперёд, то назад
+func parseLocal(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(stayLayout, value, loc)
+	if err != nil || t.Format(stayLayout) == value {
+		return t, err
+	}
+
+	// смещение пояса до перехода; переходов чаще раза в сутки не бывает
+	wall, _ := time.ParseInLocation(stayLayout, value, time.UTC)
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	return wall.Add(-time.Duration(before) * time.Second).In(loc), nil
+}
+
+// localStay переводит местные даты заезда и выезда в моменты времени по поясу апартамента
+func localStay(ap *models.Apartment, rules *models.StayRules, checkIn, checkOut string) (time.Time, time.Time, error) {
+	loc := ap.Location()
+	inTime, outTime := rules.CheckInTime, rules.CheckOutTime
+	if inTime == "" {
+		inTime = models.DefaultCheckInTime
+	}
+	if outTime == "" {
+		outTime = models.DefaultCheckOutTime
+	}
+
+	from, err := parseLocal(checkIn+" "+inTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := parseLocal(checkOut+" "+outTime, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// stayWindow — моменты заезда и выезда брони (в UTC), проверенные по правилам проживания.
+// Все нарушения возвращаются одной ValidationError
+func stayWindow(db *gorm.DB, ap *models.Apartment, dto dtos.StayDTO) (time.Time, time.Time, error) {
+	rules, err := stayRulesFor(db, ap.ID)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return resolveStay(ap, &rules, dto, time.Now())
+}
+
+// resolveStay — stayWindow с уже загруженными правилами
+func resolveStay(ap *models.Apartment, rules *models.StayRules, dto dtos.StayDTO, now time.Time) (time.Time, time.Time, error) {
+	ve := &servererrors.ValidationError{}
+	byDates := dto.CheckIn != "" || dto.CheckOut != ""
+	byTimes := !dto.TimeFrom.IsZero() || !dto.TimeTo.IsZero()
+	switch {
+	case byDates && byTimes:
+		ve.Add("check_in", "use either check_in/check_out or time_from/time_to")
+	case byDates && (dto.CheckIn == "" || dto.CheckOut == ""):
+		ve.Add("check_out", "check_in and check_out are both required")
+	case !byDates && (dto.TimeFrom.IsZero() || dto.TimeTo.IsZero()):
+		ve.Add("time_to", "time_from and time_to (or check_in and check_out) are required")
+	}
+	if len(ve.Fields) > 0 {
+		return time.Time{}, time.Time{}, ve
+	}
+
+	from, to := dto.TimeFrom, dto.TimeTo
+	if byDates {
+		var err error
+		if from, to, err = localStay(ap, rules, dto.CheckIn, dto.CheckOut); err != nil {
+			ve.Add("check_in", "dates must be YYYY-MM-DD")
+			return time.Time{}, time.Time{}, ve
+		}
+	}
+	if !to.After(from) {
+		ve.Add("time_to", "check-out must be after check-in")
+		return time.Time{}, time.Time{}, ve
+	}
+
+	loc := ap.Location()
+	checkStayRules(ve, rules, from.In(loc), to.In(loc), now)
+	if len(ve.Fields) > 0 {
+		return time.Time{}, time.Time{}, ve
+	}
+	return from.UTC(), to.UTC(), nil
+}
+
+func validClock(s string) bool {
+	_, err := time.Parse("15:04", s)
+	return err == nil
+}
+
+// validTimeZone — имя IANA; "Local" зависит от машины и не подходит
+func validTimeZone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+func (r *repositoryWithTM) GetStayRules(apID string) (models.StayRules, error) {
+	var ap models.Apartment
+	if err := r.tm.db.Select("id").Where("id = ?", apID).First(&ap).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.StayRules{}, &servererrors.NotFoundError{Entity: "apartment", Key: apID}
+		}
+		return models.StayRules{}, err
+	}
+	return stayRulesFor(r.tm.db, apID)
+}
+
+// UpdateStayRules заменяет правила целиком; уже созданные брони не перепроверяются
+func (r *repositoryWithTM) UpdateStayRules(apID string, dto *dtos.StayRulesDTO) (models.StayRules, error) {
+	rules := models.StayRules{
+		ApartmentID:    apID,
+		MinNights:      dto.MinNights,
+		MaxNights:      dto.MaxNights,
+		CheckInTime:    dto.CheckInTime,
+		CheckOutTime:   dto.CheckOutTime,
+		MinNoticeHours: dto.MinNoticeHours,
+		MaxHorizonDays: dto.MaxHorizonDays,
+		UpdatedAt:      time.Now(),
+	}
+	if rules.MinNights == 0 {
+		rules.MinNights = 1
+	}
+
+	ve := &servererrors.ValidationError{}
+	if rules.MaxNights > 0 && rules.MaxNights < rules.MinNights {
+		ve.Add("max_nights", "must not be less than min_nights")
+	}
+	var err error
+	if rules.CheckInDays, err = models.WeekdayMask(dto.CheckInDays); err != nil {
+		ve.Add("check_in_days", err.Error())
+	}
+	if rules.CheckOutDays, err = models.WeekdayMask(dto.CheckOutDays); err != nil {
+		ve.Add("check_out_days", err.Error())
+	}
+	if rules.CheckInTime != "" && !validClock(rules.CheckInTime) {
+		ve.Add("check_in_time", "must be HH:MM")
+	}
+	if rules.CheckOutTime != "" && !validClock(rules.CheckOutTime) {
+		ve.Add("check_out_time", "must be HH:MM")
+	}
+	if len(ve.Fields) > 0 {
+		return models.StayRules{}, ve
+	}
+
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.StayRules{}, err
+	}
+
+	ap, err := lockApartmentFor(tx, apID, dto.OwnerID, models.PermEditListing)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.StayRules{}, err
+	}
+	if ap.Status == models.StatusArchived {
+		_ = r.tm.rollback(tx)
+		return models.StayRules{}, &servererrors.StateConflictError{Entity: "apartment", Key: apID, Reason: "archived apartment cannot be updated"}
+	}
+
+	// UpdateAll пропускает колонки с default:now(), поэтому updated_at перечислен явно
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "ap_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min_nights", "max_nights", "check_in_days", "check_out_days",
+			"check_in_time", "check_out_time", "min_notice_hours", "max_horizon_days", "updated_at"}),
+	}).Create(&rules).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.StayRules{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.StayRules{}, err
+	}
+
+	return rules, nil
+}
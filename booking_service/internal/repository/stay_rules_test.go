@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestLocalStay(t *testing.T) {
+	tests := []struct {
+		name              string
+		zone              string
+		checkInTime       string
+		checkIn, checkOut string
+		wantFrom, wantTo  string // UTC
+		wantNights        int
+	}{
+		{"berlin spring forward", "Europe/Berlin", "", "2026-03-28", "2026-03-30",
+			"2026-03-28T14:00:00Z", "2026-03-30T09:00:00Z", 2},
+		{"berlin fall back", "Europe/Berlin", "", "2026-10-24", "2026-10-26",
+			"2026-10-24T13:00:00Z", "2026-10-26T10:00:00Z", 2},
+		{"new york spring forward", "America/New_York", "", "2026-03-07", "2026-03-09",
+			"2026-03-07T20:00:00Z", "2026-03-09T15:00:00Z", 2},
+		{"new york fall back", "America/New_York", "", "2026-10-31", "2026-11-02",
+			"2026-10-31T19:00:00Z", "2026-11-02T16:00:00Z", 2},
+		// 02:30 29.03 в Берлине не существует: берётся 03:30 CEST
+		{"berlin check-in in skipped hour", "Europe/Berlin", "02:30", "2026-03-29", "2026-03-30",
+			"2026-03-29T01:30:00Z", "2026-03-30T09:00:00Z", 1},
+		// 02:30 08.03 в Нью-Йорке не существует: берётся 03:30 EDT
+		{"new york check-in in skipped hour", "America/New_York", "02:30", "2026-03-08", "2026-03-09",
+			"2026-03-08T07:30:00Z", "2026-03-09T15:00:00Z", 1},
+		{"utc", "UTC", "", "2026-03-28", "2026-03-30",
+			"2026-03-28T15:00:00Z", "2026-03-30T11:00:00Z", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := models.Apartment{TimeZone: tt.zone}
+			rules := models.StayRules{CheckInTime: tt.checkInTime}
+
+			from, to, err := localStay(&ap, &rules, tt.checkIn, tt.checkOut)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantFrom, _ := time.Parse(time.RFC3339, tt.wantFrom)
+			wantTo, _ := time.Parse(time.RFC3339, tt.wantTo)
+			if !from.Equal(wantFrom) || !to.Equal(wantTo) {
+				t.Errorf("localStay = %v — %v, want %v — %v", from.UTC(), to.UTC(), wantFrom, wantTo)
+			}
+			if got := stayNights(from, to, ap.Location()); got != tt.wantNights {
+				t.Errorf("stayNights = %d, want %d", got, tt.wantNights)
+			}
+			if y, m, d := from.In(ap.Location()).Date(); time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Format("2006-01-02") != tt.checkIn {
+				t.Errorf("check-in date moved to %d-%02d-%02d", y, m, d)
+			}
+		})
+	}
+}
+
+func TestLocalStayRejectsBadDate(t *testing.T) {
+	ap := models.Apartment{TimeZone: "Europe/Berlin"}
+	if _, _, err := localStay(&ap, &models.StayRules{}, "2026-02-30", "2026-03-02"); err == nil {
+		t.Error("expected error for 2026-02-30")
+	}
+}
+
+func TestResolveStayAcrossDST(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	utc := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name       string
+		zone       string
+		rules      models.StayRules
+		dto        dtos.StayDTO
+		wantFrom   string // UTC, если нарушений нет
+		wantFields []string
+	}{
+		// 02:30 29.03 в Берлине пропущено: и localStay, и правило заезда дают 03:30 CEST
+		{"berlin check-in in skipped hour", "Europe/Berlin",
+			models.StayRules{MinNights: 1, CheckInTime: "02:30"},
+			dtos.StayDTO{CheckIn: "2026-03-29", CheckOut: "2026-03-30"},
+			"2026-03-29T01:30:00Z", nil},
+		{"berlin explicit time in skipped hour", "Europe/Berlin",
+			models.StayRules{MinNights: 1, CheckInTime: "02:30"},
+			dtos.StayDTO{TimeFrom: utc("2026-03-29T01:30:00Z"), TimeTo: utc("2026-03-30T09:00:00Z")},
+			"2026-03-29T01:30:00Z", nil},
+		{"berlin explicit time an hour late", "Europe/Berlin",
+			models.StayRules{MinNights: 1, CheckInTime: "02:30"},
+			dtos.StayDTO{TimeFrom: utc("2026-03-29T02:30:00Z"), TimeTo: utc("2026-03-30T09:00:00Z")},
+			"", []string{"check_in_time"}},
+		{"new york check-in in skipped hour", "America/New_York",
+			models.StayRules{MinNights: 1, CheckInTime: "02:30"},
+			dtos.StayDTO{CheckIn: "2026-03-08", CheckOut: "2026-03-09"},
+			"2026-03-08T07:30:00Z", nil},
+		// 02:30 25.10 в Берлине бывает дважды: подходят оба
+		{"berlin first repeated hour", "Europe/Berlin",
+			models.StayRules{MinNights: 1, CheckInTime: "02:30"},
+			dtos.StayDTO{TimeFrom: utc("2026-10-25T00:30:00Z"), TimeTo: utc("2026-10-26T10:00:00Z")},
+			"2026-10-25T00:30:00Z", nil},
+		{"berlin second repeated hour", "Europe/Berlin",
+			models.StayRules{MinNights: 1, CheckInTime: "02:30"},
+			dtos.StayDTO{TimeFrom: utc("2026-10-25T01:30:00Z"), TimeTo: utc("2026-10-26T10:00:00Z")},
+			"2026-10-25T01:30:00Z", nil},
+		// 49 часов через переход на зимнее время — всё равно две ночи
+		{"berlin fall back two nights", "Europe/Berlin",
+			models.StayRules{MinNights: 2, MaxNights: 2},
+			dtos.StayDTO{CheckIn: "2026-10-24", CheckOut: "2026-10-26"},
+			"2026-10-24T13:00:00Z", nil},
+		{"berlin fall back one night", "Europe/Berlin",
+			models.StayRules{MinNights: 2},
+			dtos.StayDTO{CheckIn: "2026-10-25", CheckOut: "2026-10-26"},
+			"", []string{"min_nights"}},
+		// 43 часа через переход на летнее время — две ночи, а не одна
+		{"new york spring forward two nights", "America/New_York",
+			models.StayRules{MinNights: 1, MaxNights: 1},
+			dtos.StayDTO{CheckIn: "2026-03-07", CheckOut: "2026-03-09"},
+			"", []string{"max_nights"}},
+		{"new york fall back check-out day", "America/New_York",
+			models.StayRules{MinNights: 1, CheckOutDays: 1 << int(time.Sunday)},
+			dtos.StayDTO{CheckIn: "2026-10-31", CheckOut: "2026-11-01"},
+			"2026-10-31T19:00:00Z", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ap := models.Apartment{TimeZone: tt.zone}
+
+			from, _, err := resolveStay(&ap, &tt.rules, tt.dto, now)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %+v", err)
+				}
+				if !from.Equal(utc(tt.wantFrom)) {
+					t.Errorf("from = %v, want %s", from, tt.wantFrom)
+				}
+				return
+			}
+
+			var ve *servererrors.ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			var fields []string
+			for _, f := range ve.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
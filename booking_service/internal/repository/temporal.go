@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// descriptionsAt — scope версий описания, действовавших в момент asOf (nil — действующих сейчас)
+func descriptionsAt(asOf *time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if asOf == nil {
+			return db.Where("valid_to = ?", models.OpenEnd)
+		}
+		return db.Where("valid_from <= ? AND valid_to > ?", *asOf, *asOf)
+	}
+}
+
+// descriptionJoinAt — JOIN описания с алиасом d для фильтров поиска
+func descriptionJoinAt(db *gorm.DB, asOf *time.Time) *gorm.DB {
+	if asOf == nil {
+		return db.Joins("JOIN description d ON d.ap_id = apartments.id AND d.valid_to = ?", models.OpenEnd)
+	}
+	return db.Joins("JOIN description d ON d.ap_id = apartments.id AND d.valid_from <= ? AND d.valid_to > ?", *asOf, *asOf)
+}
+
+// apartmentCreatedExpr — момент появления апартамента: первая запись истории цен или последнее обновление
+const apartmentCreatedExpr = "COALESCE((SELECT min(s.updated_at) FROM apartments_scd4 s WHERE s.ap_id = apartments.id), apartments.updated_at)"
+
+// existedAt оставляет апартаменты, которые уже существовали в момент asOf
+func existedAt(db *gorm.DB, asOf time.Time) *gorm.DB {
+	return db.Where(apartmentCreatedExpr+" <= ?", asOf)
+}
+
+// applyPricesAt заменяет текущие цены на действовавшие в момент asOf по apartments_scd4
+func applyPricesAt(db *gorm.DB, aps []models.Apartment, asOf time.Time) error {
+	if len(aps) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(aps))
+	for _, ap := range aps {
+		if ap.UpdatedAt.After(asOf) {
+			ids = append(ids, ap.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var rows []models.ApartmentSCD4
+	if err := db.Model(&models.ApartmentSCD4{}).
+		Where("ap_id IN ?", ids).
+		Where("updated_at <= ? AND invalid_since > ?", asOf, asOf).
+		Order("invalid_since").
+		Find(&rows).Error; err != nil {
+		return err
+	}
+
+	prices := map[string]decimal.Decimal{}
+	for _, row := range rows {
+		if _, ok := prices[row.ApartmentID]; !ok {
+			prices[row.ApartmentID] = row.Price
+		}
+	}
+
+	for i := range aps {
+		if price, ok := prices[aps[i].ID]; ok {
+			aps[i].Price = price
+		}
+	}
+
+	return nil
+}
+
+// apartmentAt проверяет, что апартамент существовал в asOf, и подставляет цену на тот момент
+func apartmentAt(db *gorm.DB, ap *models.Apartment, asOf time.Time) error {
+	var count int64
+	if err := existedAt(db.Model(&models.Apartment{}), asOf).Where("apartments.id = ?", ap.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return &servererrors.NotFoundError{Entity: "apartment", Key: ap.ID}
+	}
+
+	aps := []models.Apartment{*ap}
+	if err := applyPricesAt(db, aps, asOf); err != nil {
+		return err
+	}
+	ap.Price = aps[0].Price
+
+	return nil
+}
@@ -1,6 +1,15 @@
 package repository
 
-import "gorm.io/gorm"
+import (
+	"booking_service/internal/models"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
 
 type transactionManager struct {
 	db *gorm.DB
@@ -17,3 +26,27 @@ func (t *transactionManager) commit(tx *gorm.DB) error {
 func (t *transactionManager) rollback(tx *gorm.DB) error {
 	return tx.Rollback().Error
 }
+
+// emit пишет доменное событие в outbox в той же транзакции, что и само изменение;
+// дальше его забирает events.Relay
+func (t *transactionManager) emit(tx *gorm.DB, eventType, aggregateID string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.OutboxEvent{
+		ID:            uuid.New().String(),
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       string(body),
+		CreatedAt:     time.Now(),
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// uniqueViolation — нарушен уникальный индекс constraint (23505 unique_violation)
+func uniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *repositoryWithTM) InitiateTransfer(apID string, dto *dtos.TransferCreateDTO) (models.OwnershipTransfer, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.OwnershipTransfer{}, err
+	}
+
+	ap, err := lockApartmentFor(tx, apID, dto.OwnerID, models.PermOwner)
+	if err != nil {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, err
+	}
+
+	if ap.Status == models.StatusArchived {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, &servererrors.StateConflictError{
+			Entity: "apartment", Key: apID, Reason: "archived apartment cannot be transferred",
+		}
+	}
+
+	var pending int64
+	if err := tx.Model(&models.OwnershipTransfer{}).
+		Where("ap_id = ? AND status = ?", apID, models.TransferPending).
+		Count(&pending).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, err
+	}
+	if pending > 0 {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, &servererrors.StateConflictError{
+			Entity: "apartment", Key: apID, Reason: "apartment already has a pending transfer",
+		}
+	}
+
+	transfer := models.OwnershipTransfer{
+		ID:          uuid.New().String(),
+		ApartmentID: apID,
+		FromUserID:  dto.OwnerID,
+		ToUserID:    dto.ToUserID,
+		Status:      models.TransferPending,
+		CreatedAt:   time.Now(),
+	}
+	if err := tx.Create(&transfer).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.OwnershipTransfer{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Transfer %s of apartment %s to %s initiated", transfer.ID, apID, dto.ToUserID)
+	return transfer, nil
+}
+
+// ResolveTransfer: принять или отклонить может только получатель, отменить — только инициатор.
+// При принятии владелец меняется, только если объявление всё ещё принадлежит инициатору.
+func (r *repositoryWithTM) ResolveTransfer(transferID, userID, status string) (models.OwnershipTransfer, error) {
+	// TRANSACTION [BEGIN]
+	tx, err := r.tm.begin()
+	if err != nil {
+		return models.OwnershipTransfer{}, err
+	}
+
+	var transfer models.OwnershipTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("transfer_id = ?", transferID).First(&transfer).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.OwnershipTransfer{}, &servererrors.NotFoundError{Entity: "transfer", Key: transferID}
+		}
+		return models.OwnershipTransfer{}, err
+	}
+
+	allowed := transfer.ToUserID
+	if status == models.TransferCancelled {
+		allowed = transfer.FromUserID
+	}
+	if userID != allowed {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, &servererrors.ForbiddenAccessError{
+			UserId:       userID,
+			ResourceType: "transfer",
+			ResourceId:   transferID,
+		}
+	}
+
+	if transfer.Status != models.TransferPending {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, &servererrors.StateConflictError{
+			Entity: "transfer", Key: transferID, Reason: "transfer is already " + transfer.Status,
+		}
+	}
+
+	if status == models.TransferAccepted {
+		ap, err := lockApartmentFor(tx, transfer.ApartmentID, transfer.FromUserID, models.PermOwner)
+		if err != nil {
+			_ = r.tm.rollback(tx)
+			var fae *servererrors.ForbiddenAccessError
+			if errors.As(err, &fae) {
+				return models.OwnershipTransfer{}, &servererrors.StateConflictError{
+					Entity: "transfer", Key: transferID, Reason: "apartment is no longer owned by the sender",
+				}
+			}
+			return models.OwnershipTransfer{}, err
+		}
+
+		// UpdateColumn не трогает updated_at: это начало действия текущей цены (apartments_scd4)
+		if err := tx.Model(&ap).UpdateColumn("owner_id", transfer.ToUserID).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return models.OwnershipTransfer{}, err
+		}
+
+		// новый владелец не может одновременно быть соавтором
+		if err := tx.Where("ap_id = ? AND user_id = ?", ap.ID, transfer.ToUserID).Delete(&models.CoHost{}).Error; err != nil {
+			_ = r.tm.rollback(tx)
+			return models.OwnershipTransfer{}, err
+		}
+
+		if err := r.tm.emit(tx, events.ApartmentOwnerChanged, ap.ID, events.ApartmentOwnerPayload{
+			ID:          ap.ID,
+			FromOwnerID: transfer.FromUserID,
+			ToOwnerID:   transfer.ToUserID,
+			TransferID:  transfer.ID,
+		}); err != nil {
+			_ = r.tm.rollback(tx)
+			return models.OwnershipTransfer{}, err
+		}
+	}
+
+	now := time.Now()
+	transfer.Status = status
+	transfer.ResolvedAt = &now
+	transfer.ResolvedBy = &userID
+	if err := tx.Model(&transfer).Select("status", "resolved_at", "resolved_by").Updates(&transfer).Error; err != nil {
+		_ = r.tm.rollback(tx)
+		return models.OwnershipTransfer{}, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	if err := r.tm.commit(tx); err != nil {
+		return models.OwnershipTransfer{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Transfer %s of apartment %s is %s", transferID, transfer.ApartmentID, status)
+	return transfer, nil
+}
+
+// GetTransfersByUser — входящие и исходящие передачи пользователя, новые первыми
+func (r *repositoryWithTM) GetTransfersByUser(userID string) ([]models.OwnershipTransfer, error) {
+	var transfers []models.OwnershipTransfer
+	if err := r.tm.db.
+		Where("from_user_id = ? OR to_user_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	servererrors "booking_service/internal/server_errors"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ownedWebhook загружает подписку и проверяет, что она принадлежит ownerID
+func ownedWebhook(db *gorm.DB, id, ownerID string) (models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := db.Where("webhook_id = ?", id).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.WebhookSubscription{}, &servererrors.NotFoundError{Entity: "webhook", Key: id}
+		}
+		return models.WebhookSubscription{}, err
+	}
+
+	if sub.OwnerID != ownerID {
+		return models.WebhookSubscription{}, &servererrors.ForbiddenAccessError{
+			UserId:       ownerID,
+			ResourceType: "webhook",
+			ResourceId:   id,
+		}
+	}
+
+	return sub, nil
+}
+
+func (r *repositoryWithTM) CreateWebhook(dto *dtos.WebhookCreateDTO) (models.WebhookSubscription, error) {
+	if err := checkPublicURL(dto.URL); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	secret := dto.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+	}
+
+	types, err := json.Marshal(dto.EventTypes)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	sub := models.WebhookSubscription{
+		ID:         uuid.New().String(),
+		OwnerID:    dto.OwnerID,
+		URL:        dto.URL,
+		EventTypes: string(types),
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	}
+	if err := r.tm.db.Create(&sub).Error; err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	logrus.WithTime(time.Now()).Infof("Webhook %s created for owner %s", sub.ID, sub.OwnerID)
+	return sub, nil
+}
+
+func (r *repositoryWithTM) GetWebhooksByOwner(ownerID string) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := r.tm.db.Where("owner_id = ?", ownerID).Order("created_at").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (r *repositoryWithTM) UpdateWebhook(id string, dto *dtos.WebhookUpdateDTO) (models.WebhookSubscription, error) {
+	sub, err := ownedWebhook(r.tm.db, id, dto.OwnerID)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	updates := map[string]any{}
+	if dto.URL != nil {
+		if err := checkPublicURL(*dto.URL); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+		sub.URL = *dto.URL
+		updates["url"] = sub.URL
+	}
+	if dto.EventTypes != nil {
+		types, err := json.Marshal(dto.EventTypes)
+		if err != nil {
+			return models.WebhookSubscription{}, err
+		}
+		sub.EventTypes = string(types)
+		updates["event_types"] = sub.EventTypes
+	}
+	if dto.Active != nil {
+		sub.Active = *dto.Active
+		updates["active"] = sub.Active
+		// ручное включение начинает отсчёт неудач заново
+		if sub.Active {
+			sub.ConsecutiveFailures = 0
+			sub.DisabledAt = nil
+			updates["consecutive_failures"] = 0
+			updates["disabled_at"] = nil
+		}
+	}
+
+	if len(updates) == 0 {
+		return sub, nil
+	}
+	if err := r.tm.db.Model(&sub).Updates(updates).Error; err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	return sub, nil
+}
+
+func (r *repositoryWithTM) DeleteWebhook(id, ownerID string) error {
+	sub, err := ownedWebhook(r.tm.db, id, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return r.tm.db.Delete(&sub).Error
+}
+
+// GetWebhookDeliveries — журнал доставок, новые первыми
+func (r *repositoryWithTM) GetWebhookDeliveries(id, ownerID string, page dtos.PageRequest) (*[]models.WebhookDelivery, dtos.PageInfo, error) {
+	if _, err := ownedWebhook(r.tm.db, id, ownerID); err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	var total int64
+	if err := r.tm.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", id).Count(&total).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	db := r.tm.db.Where("webhook_id = ?", id)
+	if cursor != nil {
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, dtos.PageInfo{}, &servererrors.BadRequestError{Violation: "malformed cursor"}
+		}
+		db = db.Where("(created_at, delivery_id) < (?, ?)", createdAt, cursor.ID)
+	}
+
+	var deliveries []models.WebhookDelivery
+	limit := pageLimit(page.Limit)
+	if err := db.Order("created_at DESC").Order("delivery_id DESC").Limit(limit + 1).Find(&deliveries).Error; err != nil {
+		return nil, dtos.PageInfo{}, err
+	}
+
+	info := dtos.PageInfo{Total: &total}
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+		last := deliveries[limit-1]
+		info.NextCursor = encodeCursor(pageCursor{Key: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	return &deliveries, info, nil
+}
+
+func (r *repositoryWithTM) ReplayWebhookDelivery(id, deliveryID, ownerID string) (models.WebhookDelivery, error) {
+	if _, err := ownedWebhook(r.tm.db, id, ownerID); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	var dl models.WebhookDelivery
+	if err := r.tm.db.Where("webhook_id = ? AND delivery_id = ?", id, deliveryID).First(&dl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.WebhookDelivery{}, &servererrors.NotFoundError{Entity: "delivery", Key: deliveryID}
+		}
+		return models.WebhookDelivery{}, err
+	}
+
+	if dl.Status == models.DeliveryPending {
+		return models.WebhookDelivery{}, &servererrors.StateConflictError{
+			Entity: "delivery", Key: deliveryID, Reason: "delivery is already queued",
+		}
+	}
+
+	dl.Status = models.DeliveryPending
+	dl.Attempts = 0
+	dl.NextAttemptAt = time.Now()
+	if err := r.tm.db.Model(&dl).Select("status", "attempts", "next_attempt_at").Updates(&dl).Error; err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	return dl, nil
+}
@@ -0,0 +1,90 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL     = errors.New("url must be an absolute http or https url")
+	ErrPrivateAddress = errors.New("url points to a private, loopback or link-local address")
+)
+
+// служебные диапазоны, которые IsGlobalUnicast считает публичными
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// Allowed — можно ли соединяться с адресом по ссылке, которую прислал пользователь
+func Allowed(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reserved {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL проверяет ссылку при сохранении: схема http(s) и все адреса хоста публичные.
+// DNS может поменяться позже, поэтому Client проверяет адрес ещё раз при каждом соединении
+func CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve host %s", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !Allowed(addr) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// control вызывается для уже разрешённого адреса перед соединением
+func control(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !Allowed(ap.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, ap.Addr())
+	}
+	return nil
+}
+
+// NewClient — http.Client для запросов по ссылкам пользователей. Соединение с непубличным
+// адресом обрывается до отправки запроса, в том числе после редиректа и смены DNS.
+// Прокси из окружения не используется: иначе проверялся бы адрес прокси
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// dateString — дата из колонки date; у броней без дат пустая строка
+func dateString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+func guestsResponse(g models.Guests) dtos.GuestsResponse {
+	return dtos.GuestsResponse{Adults: g.Adults, Children: g.Children, Infants: g.Infants, Pets: g.Pets}
+}
+
+// === BOOKING CANCELLATION ===
+
+func (s *InnerServer) cancelBooking(c *gin.Context) {
+	var dto dtos.UserActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	b, err := s.repository.CancelBooking(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	// возврат не должен мешать отмене: при сбое его повторит сверка платежей
+	response := dtos.BookingResponse{
+		Id:          b.ID,
+		ApartmentID: b.ApartmentID,
+		Address:     b.Apartment.Address,
+		UserID:      b.UserID,
+		TimeFrom:    b.TimeFrom,
+		TimeTo:      b.TimeTo,
+		CheckIn:     dateString(b.CheckIn),
+		CheckOut:    dateString(b.CheckOut),
+		Status:      b.Status,
+		Guests:      guestsResponse(b.Guests),
+	}
+	if payment, err := s.payments.Cancel(c.Request.Context(), b.ID); err == nil {
+		response.Payment = paymentResponse(&payment)
+	} else {
+		logrus.WithField("Time", time.Now().String()).WithError(err).Warnf("Refund for booking %s postponed", b.ID)
+	}
+
+	c.JSON(http.StatusOK, response)
+	logrus.WithField("Time", time.Now().String()).Infof("200: Booking %s cancelled", b.ID)
+}
@@ -0,0 +1,510 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/repository"
+	servererrors "booking_service/internal/server_errors"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxImportRows = 1000
+	maxImportSize = 10 << 20
+)
+
+// csvColumns — колонки выгрузки и импорта CSV. info и amenities — JSON-объекты в одной ячейке,
+// id при импорте не используется
+var csvColumns = []string{
+	"id", "address", "country", "city", "street", "postal_code", "lat", "lng", "time_zone",
+	"language", "status", "price", "currency", "cleaning_fee", "info", "amenities",
+}
+
+// bulkFormat — csv или jsonl: из параметра format, иначе по Content-Type (для импорта)
+func bulkFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return "jsonl"
+	}
+	return ""
+}
+
+// fieldPath переводит путь поля из ошибки валидатора (ApartmentCreateDTO.Location.City)
+// в имена из json-тегов (location.city)
+func fieldPath(t reflect.Type, namespace string) string {
+	parts := strings.Split(namespace, ".")[1:]
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			part = part[:i]
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		f, ok := t.FieldByName(part)
+		if !ok {
+			names = append(names, strings.ToLower(part))
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = strings.ToLower(part)
+		}
+		names = append(names, name)
+		t = f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// validateImportRow применяет к строке те же binding-правила, что и POST /apartments
+func validateImportRow(dto *dtos.ApartmentCreateDTO) error {
+	err := binding.Validator.ValidateStruct(dto)
+	if err == nil {
+		return nil
+	}
+
+	var fes validator.ValidationErrors
+	if !errors.As(err, &fes) {
+		return &servererrors.BadRequestError{Violation: err.Error()}
+	}
+
+	ve := &servererrors.ValidationError{}
+	for _, fe := range fes {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		ve.Add(fieldPath(reflect.TypeOf(*dto), fe.StructNamespace()), "failed rule "+rule)
+	}
+	return ve
+}
+
+// csvApartment собирает dto из ячеек строки; пустые ячейки означают «не указано»
+func csvApartment(get func(string) string) (dtos.ApartmentCreateDTO, error) {
+	ve := &servererrors.ValidationError{}
+	dto := dtos.ApartmentCreateDTO{
+		Address:  get("address"),
+		Language: get("language"),
+		Status:   get("status"),
+		Currency: get("currency"),
+		TimeZone: get("time_zone"),
+	}
+
+	if v := get("price"); v != "" {
+		if price, err := decimal.NewFromString(v); err != nil {
+			ve.Add("price", "must be a decimal number")
+		} else {
+			dto.Price = &price
+		}
+	}
+	if v := get("cleaning_fee"); v != "" {
+		if fee, err := decimal.NewFromString(v); err != nil {
+			ve.Add("cleaning_fee", "must be a decimal number")
+		} else {
+			dto.CleaningFee = &fee
+		}
+	}
+
+	loc := dtos.AddressDTO{
+		Country:    get("country"),
+		City:       get("city"),
+		Street:     get("street"),
+		PostalCode: get("postal_code"),
+	}
+	for _, coord := range []struct {
+		name string
+		dst  **float64
+	}{{"lat", &loc.Lat}, {"lng", &loc.Lng}} {
+		if v := get(coord.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				ve.Add(coord.name, "must be a number")
+				continue
+			}
+			*coord.dst = &f
+		}
+	}
+	if loc != (dtos.AddressDTO{}) {
+		dto.Location = &loc
+	}
+
+	if v := get("info"); v != "" {
+		if err := json.Unmarshal([]byte(v), &dto.Info); err != nil {
+			ve.Add("info", "must be a JSON object of strings")
+		}
+	}
+	if v := get("amenities"); v != "" {
+		if err := json.Unmarshal([]byte(v), &dto.Amenities); err != nil {
+			ve.Add("amenities", "must be a JSON object")
+		}
+	}
+
+	if len(ve.Fields) > 0 {
+		return dto, ve
+	}
+	return dto, nil
+}
+
+// csvError — ошибка формата CSV относится к запросу; ошибки чтения тела (лимит размера) — нет
+func csvError(err error) error {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return &servererrors.BadRequestError{Violation: pe.Error()}
+	}
+	return err
+}
+
+// parseCSVImport читает файл с заголовком; неизвестная колонка — ошибка всего файла, чтобы
+// опечатка в заголовке не превращалась в молча потерянные данные
+func parseCSVImport(r io.Reader) ([]dtos.ApartmentImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &servererrors.BadRequestError{Violation: "file is empty"}
+		}
+		return nil, csvError(err)
+	}
+
+	known := map[string]bool{}
+	for _, col := range csvColumns {
+		known[col] = true
+	}
+	index := map[string]int{}
+	for i, col := range header {
+		col = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+		if !known[col] {
+			return nil, &servererrors.BadRequestError{Violation: fmt.Sprintf("unknown column %q", col)}
+		}
+		index[col] = i
+	}
+
+	rows := []dtos.ApartmentImportRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, &servererrors.BadRequestError{Violation: fmt.Sprintf("at most %d rows per import", maxImportRows)}
+		}
+
+		line, _ := reader.FieldPos(0)
+		dto, err := csvApartment(func(col string) string {
+			if i, ok := index[col]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		})
+		rows = append(rows, dtos.ApartmentImportRow{Line: line, DTO: dto, Err: err})
+	}
+
+	return rows, nil
+}
+
+// parseJSONLImport читает по объекту на строку в формате POST /apartments (или выгрузки);
+// пустые строки пропускаются
+func parseJSONLImport(r io.Reader) ([]dtos.ApartmentImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportSize)
+
+	rows := []dtos.ApartmentImportRow{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, &servererrors.BadRequestError{Violation: fmt.Sprintf("at most %d rows per import", maxImportRows)}
+		}
+
+		var obj struct {
+			ID string `json:"id"` // id из выгрузки не используется
+			dtos.ApartmentCreateDTO
+		}
+		row := dtos.ApartmentImportRow{Line: line}
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&obj); err != nil {
+			row.Err = &servererrors.BadRequestError{Violation: "invalid JSON: " + err.Error()}
+		}
+		row.DTO = obj.ApartmentCreateDTO
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func importRowResponse(row *dtos.ApartmentImportRow, dryRun bool) dtos.ImportRowResponse {
+	response := dtos.ImportRowResponse{Line: row.Line, ApartmentID: row.ApartmentID}
+	switch {
+	case row.Err != nil:
+		response.Status = "failed"
+		response.Error = row.Err.Error()
+		var ve *servererrors.ValidationError
+		if errors.As(row.Err, &ve) {
+			response.Fields = ve.Fields
+		}
+		var bre *servererrors.BadRequestError
+		if errors.As(row.Err, &bre) {
+			response.Error = bre.Violation
+		}
+	case row.ApartmentID != "":
+		response.Status = "created"
+	case dryRun:
+		response.Status = "valid"
+	default:
+		response.Status = "skipped"
+	}
+	return response
+}
+
+// === BULK IMPORT / EXPORT ===
+
+// importApartments — POST /owners/:id/apartments/import?mode=atomic|partial&dry_run=true;
+// тело — CSV или JSON Lines. Все строки создаются от имени владельца из пути
+func (s *InnerServer) importApartments(c *gin.Context) {
+	ownerID := c.Param("id")
+	if _, err := uuid.Parse(ownerID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner id must be a valid uuid"})
+		return
+	}
+
+	mode := c.DefaultQuery("mode", "atomic")
+	if mode != "atomic" && mode != "partial" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be atomic or partial"})
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be a boolean"})
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	var rows []dtos.ApartmentImportRow
+	switch bulkFormat(c) {
+	case "csv":
+		rows, err = parseCSVImport(body)
+	case "jsonl":
+		rows, err = parseJSONLImport(body)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "send text/csv or application/x-ndjson, or set format=csv|jsonl"})
+		return
+	}
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file must be at most %d bytes", maxImportSize)})
+			return
+		}
+		writeError(c, err)
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file has no rows"})
+		return
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Err != nil {
+			continue
+		}
+		if row.DTO.OwnerID != "" && row.DTO.OwnerID != ownerID {
+			ve := &servererrors.ValidationError{}
+			ve.Add("owner_id", "must be empty or match the owner in the path")
+			row.Err = ve
+			continue
+		}
+		row.DTO.OwnerID = ownerID
+		if row.Err = validateImportRow(&row.DTO); row.Err != nil {
+			continue
+		}
+		s.resolveLocation(c.Request.Context(), &row.DTO)
+	}
+
+	if err := s.repository.ImportApartments(rows, mode == "partial", dryRun); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	report := dtos.ImportReportResponse{DryRun: dryRun, Mode: mode, Total: len(rows), Rows: []dtos.ImportRowResponse{}}
+	for i := range rows {
+		row := importRowResponse(&rows[i], dryRun)
+		switch row.Status {
+		case "failed":
+			report.Failed++
+		case "created":
+			report.Created++
+			report.Valid++
+		default:
+			report.Valid++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	status := http.StatusOK
+	switch {
+	case report.Created > 0:
+		status = http.StatusCreated
+	case mode == "atomic" && report.Failed > 0:
+		status = http.StatusUnprocessableEntity
+	}
+
+	c.JSON(status, report)
+	logrus.WithField("Time", time.Now().String()).
+		Infof("%d: Apartment import for owner %s: %d created, %d failed", status, ownerID, report.Created, report.Failed)
+}
+
+func exportRow(ap *models.Apartment) dtos.ApartmentExportRow {
+	row := dtos.ApartmentExportRow{
+		Id:          ap.ID,
+		Address:     ap.Address,
+		Status:      ap.Status,
+		Price:       ap.Price,
+		Currency:    ap.Currency,
+		TimeZone:    ap.TimeZone,
+		Language:    repository.LanguageCode(ap.SearchLang),
+		CleaningFee: ap.CleaningFee,
+		Info:        map[string]string{},
+		Amenities:   map[string]any{},
+	}
+	if ap.City != "" || ap.Street != "" {
+		row.Location = &dtos.AddressDTO{
+			Country:    ap.Country,
+			City:       ap.City,
+			Street:     ap.Street,
+			PostalCode: ap.PostalCode,
+			Lat:        ap.Lat,
+			Lng:        ap.Lng,
+		}
+	}
+	if len(ap.Descriptions) > 0 {
+		row.Info = descriptionInfo(&ap.Descriptions[0])
+		row.Amenities = ap.Descriptions[0].AmenityMap()
+	}
+	return row
+}
+
+func csvRecord(row *dtos.ApartmentExportRow) []string {
+	cells := map[string]string{
+		"id":           row.Id,
+		"address":      row.Address,
+		"time_zone":    row.TimeZone,
+		"language":     row.Language,
+		"status":       row.Status,
+		"price":        row.Price.String(),
+		"currency":     row.Currency,
+		"cleaning_fee": row.CleaningFee.String(),
+	}
+	if loc := row.Location; loc != nil {
+		cells["country"] = loc.Country
+		cells["city"] = loc.City
+		cells["street"] = loc.Street
+		cells["postal_code"] = loc.PostalCode
+		if loc.Lat != nil && loc.Lng != nil {
+			cells["lat"] = strconv.FormatFloat(*loc.Lat, 'f', -1, 64)
+			cells["lng"] = strconv.FormatFloat(*loc.Lng, 'f', -1, 64)
+		}
+	}
+	if len(row.Info) > 0 {
+		b, _ := json.Marshal(row.Info)
+		cells["info"] = string(b)
+	}
+	if len(row.Amenities) > 0 {
+		b, _ := json.Marshal(row.Amenities)
+		cells["amenities"] = string(b)
+	}
+
+	record := make([]string, len(csvColumns))
+	for i, col := range csvColumns {
+		record[i] = cells[col]
+	}
+	return record
+}
+
+// exportApartments — GET /owners/:id/apartments/export?format=csv|jsonl&status=;
+// файл в том же формате, что принимает импорт
+func (s *InnerServer) exportApartments(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.StatusDraft, models.StatusPublished, models.StatusUnlisted, models.StatusArchived:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown status filter"})
+		return
+	}
+
+	aps, err := s.repository.ExportApartments(c.Param("id"), status)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="apartments.`+format+`"`)
+	if format == "jsonl" {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		enc := json.NewEncoder(c.Writer)
+		for i := range aps {
+			if err := enc.Encode(exportRow(&aps[i])); err != nil {
+				logrus.WithError(err).Warn("Apartment export interrupted")
+				return
+			}
+		}
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(csvColumns)
+	for i := range aps {
+		row := exportRow(&aps[i])
+		_ = w.Write(csvRecord(&row))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logrus.WithError(err).Warn("Apartment export interrupted")
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"booking_service/internal/calendar"
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// feedURL — полная ссылка на ленту для вставки в другую площадку; схема берётся у прокси, если он есть
+func feedURL(c *gin.Context, token string) string {
+	scheme := c.GetHeader("X-Forwarded-Proto")
+	if scheme == "" {
+		scheme = "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+	}
+	return scheme + "://" + c.Request.Host + "/calendars/" + token + ".ics"
+}
+
+func calendarImportResponse(imp *models.CalendarImport) dtos.CalendarImportResponse {
+	return dtos.CalendarImportResponse{
+		Id:           imp.ID,
+		ApartmentID:  imp.ApartmentID,
+		URL:          imp.URL,
+		Name:         imp.Name,
+		CreatedBy:    imp.CreatedBy,
+		CreatedAt:    imp.CreatedAt,
+		LastSyncedAt: imp.LastSyncedAt,
+		LastError:    imp.LastError,
+		EventCount:   imp.EventCount,
+	}
+}
+
+func calendarConflictResponse(cf *models.CalendarConflict) dtos.CalendarConflictResponse {
+	b := &cf.Booking
+	return dtos.CalendarConflictResponse{
+		Id:       cf.ID,
+		ImportID: cf.Block.ImportID,
+		Summary:  cf.Block.Summary,
+		TimeFrom: cf.Block.TimeFrom,
+		TimeTo:   cf.Block.TimeTo,
+		Booking: dtos.BookingResponse{
+			Id:          b.ID,
+			ApartmentID: b.ApartmentID,
+			UserID:      b.UserID,
+			TimeFrom:    b.TimeFrom,
+			TimeTo:      b.TimeTo,
+			CheckIn:     dateString(b.CheckIn),
+			CheckOut:    dateString(b.CheckOut),
+			Status:      b.Status,
+			Guests:      guestsResponse(b.Guests),
+		},
+		DetectedAt: cf.DetectedAt,
+	}
+}
+
+// === CALENDAR SYNC ===
+
+// getCalendarFeed — GET /calendars/:token.ics; доступ по секретному токену, без user_id
+func (s *InnerServer) getCalendarFeed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("feed"), ".ics")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendar not found"})
+		return
+	}
+
+	ap, bookings, blocks, err := s.repository.GetCalendarByToken(token)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar.Export(&ap, bookings, blocks, time.Now())))
+}
+
+// getCalendarFeedLink — GET /apartments/:id/calendar/feed?user_id=...; нужно право manage_bookings
+func (s *InnerServer) getCalendarFeedLink(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	feed, err := s.repository.GetCalendarFeed(c.Param("id"), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.CalendarFeedResponse{ApartmentID: feed.ApartmentID, URL: feedURL(c, feed.Token), CreatedAt: feed.CreatedAt})
+}
+
+func (s *InnerServer) rotateCalendarFeed(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	feed, err := s.repository.RotateCalendarFeed(c.Param("id"), dto.OwnerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dtos.CalendarFeedResponse{ApartmentID: feed.ApartmentID, URL: feedURL(c, feed.Token), CreatedAt: feed.CreatedAt})
+}
+
+func (s *InnerServer) postCalendarImport(c *gin.Context) {
+	var dto dtos.CalendarImportDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	imp, err := s.repository.AddCalendarImport(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, calendarImportResponse(&imp))
+	logrus.WithField("Time", time.Now().String()).Infof("201: Calendar import %s added", imp.ID)
+}
+
+func (s *InnerServer) getCalendarImports(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	imports, err := s.repository.GetCalendarImports(c.Param("id"), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.CalendarImportResponse{}
+	for i := range imports {
+		response = append(response, calendarImportResponse(&imports[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(response), "imports": response})
+}
+
+func (s *InnerServer) deleteCalendarImport(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	if err := s.repository.DeleteCalendarImport(c.Param("id"), c.Param("import_id"), dto.OwnerID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getCalendarConflicts — GET /apartments/:id/calendar/conflicts?user_id=...; импортированные события,
+// легшие на уже принятые брони. Разрешаются отменой брони или правкой во внешнем календаре
+func (s *InnerServer) getCalendarConflicts(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	conflicts, err := s.repository.GetCalendarConflicts(c.Param("id"), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.CalendarConflictResponse{}
+	for i := range conflicts {
+		response = append(response, calendarConflictResponse(&conflicts[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(response), "conflicts": response})
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func transferResponse(t *models.OwnershipTransfer) dtos.TransferResponse {
+	return dtos.TransferResponse{
+		Id:          t.ID,
+		ApartmentID: t.ApartmentID,
+		FromUserID:  t.FromUserID,
+		ToUserID:    t.ToUserID,
+		Status:      t.Status,
+		CreatedAt:   t.CreatedAt,
+		ResolvedAt:  t.ResolvedAt,
+		ResolvedBy:  t.ResolvedBy,
+	}
+}
+
+func coHostResponse(ch *models.CoHost) dtos.CoHostResponse {
+	return dtos.CoHostResponse{
+		UserID:      ch.UserID,
+		Permissions: ch.Permissions(),
+		GrantedBy:   ch.GrantedBy,
+		CreatedAt:   ch.CreatedAt,
+	}
+}
+
+// === OWNERSHIP TRANSFER ===
+
+func (s *InnerServer) initiateTransfer(c *gin.Context) {
+	var dto dtos.TransferCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	t, err := s.repository.InitiateTransfer(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, transferResponse(&t))
+	logrus.WithField("Time", time.Now().String()).Infof("201: Transfer %s initiated", t.ID)
+}
+
+// resolveTransfer возвращает обработчик accept/decline/cancel с фиксированным итоговым статусом
+func (s *InnerServer) resolveTransfer(status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto dtos.UserActionDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+
+		t, err := s.repository.ResolveTransfer(c.Param("id"), dto.UserID, status)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, transferResponse(&t))
+		logrus.WithField("Time", time.Now().String()).Infof("200: Transfer %s %s", t.ID, t.Status)
+	}
+}
+
+func (s *InnerServer) getTransfersByUser(c *gin.Context) {
+	ts, err := s.repository.GetTransfersByUser(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.TransferResponse{}
+	for i := range ts {
+		response = append(response, transferResponse(&ts[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(response), "transfers": response})
+}
+
+// === CO-HOSTS ===
+
+func (s *InnerServer) setCoHost(c *gin.Context) {
+	userID := c.Param("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	var dto dtos.CoHostDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ch, err := s.repository.SetCoHost(c.Param("id"), userID, &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, coHostResponse(&ch))
+}
+
+func (s *InnerServer) removeCoHost(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	if err := s.repository.RemoveCoHost(c.Param("id"), c.Param("user_id"), dto.OwnerID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getCoHosts — GET /apartments/:id/cohosts?owner_id=..., виден только владельцу
+func (s *InnerServer) getCoHosts(c *gin.Context) {
+	ownerID := c.Query("owner_id")
+	if _, err := uuid.Parse(ownerID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id must be a valid uuid"})
+		return
+	}
+
+	chs, err := s.repository.GetCoHosts(c.Param("id"), ownerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.CoHostResponse{}
+	for i := range chs {
+		response = append(response, coHostResponse(&chs[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(response), "cohosts": response})
+}
+
+// getApartmentBookings — GET /apartments/:id/bookings?user_id=..., для владельца и соавторов с manage_bookings
+func (s *InnerServer) getApartmentBookings(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	bs, pageInfo, err := s.repository.GetApartmentBookings(c.Param("id"), userID, page)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.BookingResponse{}
+	for _, booking := range *bs {
+		response = append(response, dtos.BookingResponse{
+			Id:          booking.ID,
+			ApartmentID: booking.ApartmentID,
+			UserID:      booking.UserID,
+			TimeFrom:    booking.TimeFrom,
+			TimeTo:      booking.TimeTo,
+			CheckIn:     dateString(booking.CheckIn),
+			CheckOut:    dateString(booking.CheckOut),
+			Status:      booking.Status,
+			Guests:      guestsResponse(booking.Guests),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"bookings":    response,
+		"next_cursor": pageInfo.NextCursor,
+	})
+}
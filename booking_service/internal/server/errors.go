@@ -0,0 +1,66 @@
+package server
+
+import (
+	servererrors "booking_service/internal/server_errors"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// writeError переводит типизированные ошибки репозитория в HTTP-ответ
+func writeError(c *gin.Context, err error) {
+	var nfe *servererrors.NotFoundError
+	if errors.As(err, &nfe) {
+		c.JSON(http.StatusNotFound, gin.H{"error": nfe.Error()})
+		logrus.WithField("Time", time.Now().String()).Infof("404: %v", nfe)
+		return
+	}
+
+	var fae *servererrors.ForbiddenAccessError
+	if errors.As(err, &fae) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden request"})
+		logrus.WithField("Time", time.Now().String()).Infof("403: %v", fae)
+		return
+	}
+
+	var ve *servererrors.ValidationError
+	if errors.As(err, &ve) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request form", "fields": ve.Fields})
+		logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
+		return
+	}
+
+	var bre *servererrors.BadRequestError
+	if errors.As(err, &bre) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": bre.Violation})
+		logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
+		return
+	}
+
+	var aee *servererrors.AlreadyExistsError
+	if errors.As(err, &aee) {
+		c.JSON(http.StatusConflict, gin.H{"error": aee.Error()})
+		logrus.WithField("Time", time.Now().String()).Info("409: Conflict")
+		return
+	}
+
+	var oe *servererrors.OverlapError
+	if errors.As(err, &oe) {
+		c.JSON(http.StatusConflict, gin.H{"error": "time overlap with other booking"})
+		logrus.WithField("Time", time.Now().String()).Info("409: Conflict")
+		return
+	}
+
+	var sce *servererrors.StateConflictError
+	if errors.As(err, &sce) {
+		c.JSON(http.StatusConflict, gin.H{"error": sce.Reason})
+		logrus.WithField("Time", time.Now().String()).Infof("409: %v", sce)
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	logrus.WithField("Time", time.Now().String()).WithError(err).Warn("500: Internal Server Error")
+}
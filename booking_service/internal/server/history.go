@@ -0,0 +1,81 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// === HISTORY ===
+
+// getApartmentHistory отдаёт объединённую хронологию цен и версий описания.
+// Example: GET /apartments/:id/history?from=2025-01-01T00:00:00Z&to=2025-06-01T00:00:00Z
+func (s *InnerServer) getApartmentHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	var q dtos.HistoryQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be RFC 3339 timestamps"})
+		return
+	}
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	ap, err := s.repository.GetApartmentHistory(id, q.From, q.To)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	timeline := []dtos.HistoryEntry{}
+
+	for _, h := range ap.History {
+		price := h.Price
+		invalidSince := h.InvalidSince
+		timeline = append(timeline, dtos.HistoryEntry{
+			Type:      "price",
+			ValidFrom: h.UpdatedAt,
+			ValidTo:   &invalidSince,
+			Price:     &price,
+		})
+	}
+
+	// текущая цена действует с последнего обновления апартамента
+	if q.To == nil || !ap.UpdatedAt.After(*q.To) {
+		price := ap.Price
+		timeline = append(timeline, dtos.HistoryEntry{
+			Type:      "price",
+			ValidFrom: ap.UpdatedAt,
+			Price:     &price,
+		})
+	}
+
+	for i := range ap.Descriptions {
+		desc := &ap.Descriptions[i]
+		entry := dtos.HistoryEntry{
+			Type:      "description",
+			ValidFrom: desc.ValidFrom,
+			Info:      descriptionInfo(desc),
+			Amenities: desc.AmenityMap(),
+		}
+		if !desc.IsCurrent() {
+			validTo := desc.ValidTo
+			entry.ValidTo = &validTo
+		}
+		timeline = append(timeline, entry)
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].ValidFrom.Before(timeline[j].ValidFrom)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"apartment_id": ap.ID,
+		"count":        len(timeline),
+		"timeline":     timeline,
+	})
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/invoices"
+	"booking_service/internal/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func billingDetailsResponse(userID string, d models.BillingDetails) dtos.BillingDetailsResponse {
+	return dtos.BillingDetailsResponse{
+		UserID:  userID,
+		Name:    d.Name,
+		Company: d.Company,
+		TaxID:   d.TaxID,
+		Address: d.Address,
+	}
+}
+
+func invoiceResponse(inv *models.Invoice) dtos.InvoiceResponse {
+	lines := make([]dtos.InvoiceLineResponse, 0, len(inv.Lines))
+	for _, l := range inv.Lines {
+		lines = append(lines, dtos.InvoiceLineResponse{
+			Kind:        l.Kind,
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			Amount:      l.Amount,
+			TaxRate:     l.TaxRate,
+			TaxAmount:   l.TaxAmount,
+		})
+	}
+
+	return dtos.InvoiceResponse{
+		Id:              inv.ID,
+		Number:          inv.Number,
+		IssuedAt:        inv.IssuedAt,
+		BookingID:       inv.BookingID,
+		Guest:           billingDetailsResponse(inv.GuestID, inv.Guest),
+		Host:            billingDetailsResponse(inv.HostID, inv.Host),
+		PropertyAddress: inv.PropertyAddress,
+		TimeFrom:        inv.TimeFrom.In(inv.Location()),
+		TimeTo:          inv.TimeTo.In(inv.Location()),
+		Nights:          inv.Nights,
+		Currency:        inv.Currency,
+		Lines:           lines,
+		Subtotal:        inv.Subtotal,
+		TaxTotal:        inv.TaxTotal,
+		Total:           inv.Total,
+	}
+}
+
+// === INVOICES ===
+
+// getInvoice — GET /bookings/:id/invoice?user_id=&format=json|pdf. Формат можно выбрать и через
+// Accept: application/pdf. Счёт выставляется при первом запросе после оплаты
+func (s *InnerServer) getInvoice(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" && strings.Contains(c.GetHeader("Accept"), "application/pdf") {
+		format = "pdf"
+	}
+	if format != "" && format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or pdf"})
+		return
+	}
+
+	inv, err := s.repository.GetInvoice(c.Param("id"), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if format != "pdf" {
+		c.JSON(http.StatusOK, invoiceResponse(&inv))
+		return
+	}
+
+	body, err := invoices.RenderPDF(&inv)
+	if err != nil {
+		logrus.WithField("Time", time.Now().String()).Errorf("500: could not render invoice %s: %v", inv.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not render invoice"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, inv.Number))
+	c.Data(http.StatusOK, "application/pdf", body)
+}
+
+func (s *InnerServer) getBillingProfile(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a valid uuid"})
+		return
+	}
+
+	profile, err := s.repository.GetBillingProfile(userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, billingDetailsResponse(profile.UserID, profile.BillingDetails))
+}
+
+// updateBillingProfile — PUT /users/:id/billing-profile; уже выставленные счета не меняются
+func (s *InnerServer) updateBillingProfile(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a valid uuid"})
+		return
+	}
+
+	var dto dtos.BillingProfileDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	profile, err := s.repository.UpdateBillingProfile(userID, &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, billingDetailsResponse(profile.UserID, profile.BillingDetails))
+}
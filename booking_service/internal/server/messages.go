@@ -0,0 +1,356 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/storage"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxAttachmentsPerMessage = 5
+	maxAttachmentSize        = 10 << 20
+)
+
+// attachmentTypes — что можно прикладывать к сообщениям (по сигнатуре файла, а не по имени)
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+func attachmentKey(threadID, attachmentID, fileName string) string {
+	return "messages/" + threadID + "/" + attachmentID + strings.ToLower(filepath.Ext(fileName))
+}
+
+func (s *InnerServer) threadResponse(t *models.Thread) dtos.ThreadResponse {
+	return dtos.ThreadResponse{
+		Id:            t.ID,
+		ApartmentID:   t.ApartmentID,
+		BookingID:     t.BookingID,
+		GuestID:       t.GuestID,
+		HostID:        t.Apartment.OwnerID,
+		Unread:        t.Unread,
+		CreatedAt:     t.CreatedAt,
+		LastMessageAt: t.LastMessageAt,
+	}
+}
+
+// messageResponse: read — второй участник открыл переписку не раньше, чем пришло сообщение
+func (s *InnerServer) messageResponse(m *models.Message, reads []models.ThreadRead) dtos.MessageResponse {
+	response := dtos.MessageResponse{
+		Id:          m.ID,
+		SenderID:    m.SenderID,
+		Body:        m.Body,
+		Attachments: []dtos.AttachmentResponse{},
+		CreatedAt:   m.CreatedAt,
+	}
+	for _, a := range m.Attachments {
+		response.Attachments = append(response.Attachments, dtos.AttachmentResponse{
+			Id:          a.ID,
+			FileName:    a.FileName,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			Url:         "/threads/" + m.ThreadID + "/attachments/" + a.ID,
+		})
+	}
+	for _, r := range reads {
+		if r.UserID != m.SenderID && !r.LastReadAt.Before(m.CreatedAt) {
+			response.Read = true
+		}
+	}
+	return response
+}
+
+// storeAttachments проверяет и сохраняет файлы; при ошибке уже сохранённые удаляются
+func (s *InnerServer) storeAttachments(ctx context.Context, threadID string, files []*multipart.FileHeader) ([]models.MessageAttachment, int, string) {
+	stored := []models.MessageAttachment{}
+	fail := func(code int, msg string) ([]models.MessageAttachment, int, string) {
+		s.removeAttachments(ctx, stored)
+		return nil, code, msg
+	}
+
+	for _, fh := range files {
+		if fh.Size > maxAttachmentSize {
+			return fail(http.StatusRequestEntityTooLarge, "file "+fh.Filename+" exceeds 10 MB")
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			return fail(http.StatusBadRequest, "could not read file "+fh.Filename)
+		}
+		raw, err := io.ReadAll(io.LimitReader(f, maxAttachmentSize+1))
+		_ = f.Close()
+		if err != nil || len(raw) > maxAttachmentSize {
+			return fail(http.StatusBadRequest, "could not read file "+fh.Filename)
+		}
+
+		contentType := http.DetectContentType(raw)
+		if !attachmentTypes[strings.TrimSpace(strings.Split(contentType, ";")[0])] {
+			return fail(http.StatusUnsupportedMediaType, fh.Filename+": unsupported attachment type")
+		}
+
+		a := models.MessageAttachment{
+			ID:          uuid.New().String(),
+			FileName:    filepath.Base(fh.Filename),
+			ContentType: contentType,
+			Size:        int64(len(raw)),
+		}
+		a.Key = attachmentKey(threadID, a.ID, a.FileName)
+
+		if err := s.attachments.Put(ctx, a.Key, bytes.NewReader(raw), a.Size, contentType); err != nil {
+			logrus.WithField("Time", time.Now().String()).Warnf("500: storage failure: %v", err)
+			return fail(http.StatusInternalServerError, "failed to store attachment")
+		}
+		stored = append(stored, a)
+	}
+
+	return stored, 0, ""
+}
+
+func (s *InnerServer) removeAttachments(ctx context.Context, as []models.MessageAttachment) {
+	for _, a := range as {
+		if err := s.attachments.Delete(ctx, a.Key); err != nil {
+			logrus.WithField("Time", time.Now().String()).Warnf("failed to delete object %s: %v", a.Key, err)
+		}
+	}
+}
+
+// openAttachment читает вложение из приватного хранилища. Вложения, загруженные до его
+// появления, лежат в публичном — при первом обращении они переносятся оттуда
+func (s *InnerServer) openAttachment(ctx context.Context, a *models.MessageAttachment) (io.ReadCloser, error) {
+	rc, err := s.attachments.Open(ctx, a.Key)
+	if !errors.Is(err, storage.ErrNotFound) {
+		return rc, err
+	}
+
+	legacy, err := s.storage.Open(ctx, a.Key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(legacy)
+	_ = legacy.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachments.Put(ctx, a.Key, bytes.NewReader(raw), int64(len(raw)), a.ContentType); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Delete(ctx, a.Key); err != nil {
+		logrus.WithField("Time", time.Now().String()).Warnf("failed to delete public copy of %s: %v", a.Key, err)
+	}
+	return io.NopCloser(bytes.NewReader(raw)), nil
+}
+
+// === MESSAGING ===
+
+func (s *InnerServer) startInquiry(c *gin.Context) {
+	var dto dtos.InquiryDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	t, m, err := s.repository.StartInquiry(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"thread": s.threadResponse(&t), "message": s.messageResponse(&m, nil)})
+	logrus.WithField("Time", time.Now().String()).Infof("201: Inquiry thread %s", t.ID)
+}
+
+// getBookingThread — GET /bookings/:id/thread?user_id=..., создаёт переписку при первом обращении
+func (s *InnerServer) getBookingThread(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	t, err := s.repository.GetBookingThread(c.Param("id"), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, s.threadResponse(&t))
+}
+
+func (s *InnerServer) getThreadsByUser(c *gin.Context) {
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	ts, pageInfo, err := s.repository.GetThreadsByUser(c.Param("id"), page)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.ThreadResponse{}
+	for i := range *ts {
+		response = append(response, s.threadResponse(&(*ts)[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"threads":     response,
+		"next_cursor": pageInfo.NextCursor,
+	})
+}
+
+// getThreadMessages — GET /threads/:id/messages?user_id=...&limit=&cursor=, новые первыми
+func (s *InnerServer) getThreadMessages(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	ms, reads, pageInfo, err := s.repository.GetThreadMessages(c.Param("id"), userID, page)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.MessageResponse{}
+	for i := range *ms {
+		response = append(response, s.messageResponse(&(*ms)[i], reads))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"messages":    response,
+		"next_cursor": pageInfo.NextCursor,
+	})
+}
+
+// postMessage принимает JSON {user_id, body} или multipart-форму с файлами в поле attachments
+func (s *InnerServer) postMessage(c *gin.Context) {
+	threadID := c.Param("id")
+
+	var dto dtos.MessageDTO
+	if err := c.ShouldBind(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["attachments"]
+	}
+	if len(files) > maxAttachmentsPerMessage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at most 5 attachments per message"})
+		return
+	}
+	if strings.TrimSpace(dto.Body) == "" && len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message must have a body or attachments"})
+		return
+	}
+
+	if _, err := s.repository.CheckThreadParticipant(threadID, dto.UserID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	attachments, code, msg := s.storeAttachments(ctx, threadID, files)
+	if code != 0 {
+		c.JSON(code, gin.H{"error": msg})
+		return
+	}
+
+	m := models.Message{
+		ID:          uuid.New().String(),
+		ThreadID:    threadID,
+		SenderID:    dto.UserID,
+		Body:        dto.Body,
+		Attachments: attachments,
+	}
+	for i := range m.Attachments {
+		m.Attachments[i].MessageID = m.ID
+	}
+
+	if err := s.repository.AddMessage(&m); err != nil {
+		s.removeAttachments(ctx, attachments)
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, s.messageResponse(&m, nil))
+}
+
+// getThreadAttachment — GET /threads/:id/attachments/:attachment_id?user_id=..., только участникам переписки
+func (s *InnerServer) getThreadAttachment(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	a, err := s.repository.GetThreadAttachment(c.Param("id"), c.Param("attachment_id"), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	rc, err := s.openAttachment(c.Request.Context(), &a)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment file is missing"})
+		return
+	}
+	if err != nil {
+		logrus.WithField("Time", time.Now().String()).Warnf("500: storage failure: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read attachment"})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, a.Size, a.ContentType, rc, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}),
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (s *InnerServer) markThreadRead(c *gin.Context) {
+	var dto dtos.UserActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	if err := s.repository.MarkThreadRead(c.Param("id"), dto.UserID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func preferencesResponse(p *models.NotificationPreference) dtos.NotificationPreferencesResponse {
+	return dtos.NotificationPreferencesResponse{
+		UserID: p.UserID,
+		Locale: p.Locale,
+		Email:  p.Email,
+		Push:   p.Push,
+		InApp:  p.InApp,
+	}
+}
+
+// === NOTIFICATIONS ===
+
+func (s *InnerServer) getNotificationPreferences(c *gin.Context) {
+	prefs, err := s.repository.GetNotificationPreferences(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferencesResponse(&prefs))
+}
+
+func (s *InnerServer) updateNotificationPreferences(c *gin.Context) {
+	var dto dtos.NotificationPreferencesDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	prefs, err := s.repository.UpdateNotificationPreferences(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferencesResponse(&prefs))
+}
+
+// getNotifications — GET /users/:id/notifications?unread=true&limit=&cursor=
+func (s *InnerServer) getNotifications(c *gin.Context) {
+	var q dtos.NotificationQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	ns, pageInfo, err := s.repository.GetNotifications(c.Param("id"), q)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.NotificationResponse{}
+	for _, n := range *ns {
+		response = append(response, dtos.NotificationResponse{
+			Id:        n.ID,
+			Template:  n.Template,
+			Subject:   n.Subject,
+			Body:      n.Body,
+			CreatedAt: n.CreatedAt,
+			ReadAt:    n.ReadAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":         len(response),
+		"total":         pageInfo.Total,
+		"notifications": response,
+		"next_cursor":   pageInfo.NextCursor,
+	})
+}
+
+func (s *InnerServer) markNotificationRead(c *gin.Context) {
+	var dto dtos.UserActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	if err := s.repository.MarkNotificationRead(c.Param("id"), dto.UserID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
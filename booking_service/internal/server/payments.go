@@ -0,0 +1,138 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/payments"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const maxPaymentWebhookSize = 1 << 20
+
+func paymentResponse(p *models.Payment) *dtos.PaymentResponse {
+	if p == nil {
+		return nil
+	}
+	return &dtos.PaymentResponse{
+		Id:             p.ID,
+		Status:         p.Status,
+		Amount:         p.Amount,
+		Currency:       p.Currency,
+		RefundedAmount: p.RefundedAmount,
+		FailureReason:  p.FailureReason,
+	}
+}
+
+// === PAYMENTS ===
+
+// getBookingPayment — GET /bookings/:id/payment?user_id=; видят гость и те, кто управляет бронями
+func (s *InnerServer) getBookingPayment(c *gin.Context) {
+	userID := c.Query("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a valid uuid"})
+		return
+	}
+
+	payment, err := s.repository.GetBookingPayment(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if payment.Booking.UserID != userID {
+		if err := s.repository.CheckApartmentAccess(payment.Booking.ApartmentID, userID, models.PermManageBookings); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, paymentResponse(&payment))
+}
+
+// handlePaymentWebhook принимает асинхронные уведомления провайдера. 2xx — событие учтено
+// (или не нужно), иначе провайдер доставит его повторно
+func (s *InnerServer) handlePaymentWebhook(c *gin.Context) {
+	if c.Param("provider") != s.payments.ProviderName() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown payment provider"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPaymentWebhookSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read body"})
+		return
+	}
+
+	if err := s.payments.HandleWebhook(c.Request.Context(), c.Request.Header, body); err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			logrus.WithField("Time", time.Now().String()).Info("400: payment webhook with invalid signature")
+			return
+		}
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// === BALANCE & PAYOUTS ===
+
+func (s *InnerServer) getOwnerBalance(c *gin.Context) {
+	ownerID := c.Param("id")
+	balances, err := s.repository.GetOwnerBalance(ownerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.BalanceResponse{}
+	for _, b := range balances {
+		response = append(response, dtos.BalanceResponse{
+			Currency:  b.Currency,
+			Pending:   b.Pending,
+			Available: b.Available,
+			PaidOut:   b.PaidOut,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner_id": ownerID, "balances": response})
+}
+
+func (s *InnerServer) getOwnerPayouts(c *gin.Context) {
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	payouts, pageInfo, err := s.repository.GetOwnerPayouts(c.Param("id"), page)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.PayoutResponse{}
+	for _, p := range *payouts {
+		response = append(response, dtos.PayoutResponse{
+			Id:        p.ID,
+			Amount:    p.Amount,
+			Currency:  p.Currency,
+			CreatedAt: p.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"payouts":     response,
+		"next_cursor": pageInfo.NextCursor,
+	})
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/photos"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const maxPhotosPerUpload = 20
+
+func (s *InnerServer) photoResponse(p *models.ApartmentPhoto) dtos.PhotoResponse {
+	thumbs := make(map[string]string, len(photos.ThumbnailSizes))
+	for _, size := range photos.ThumbnailSizes {
+		thumbs[size.Name] = s.storage.URL(photos.ThumbnailKey(p.ApartmentID, p.ID, size.Name))
+	}
+
+	return dtos.PhotoResponse{
+		Id:         p.ID,
+		Position:   p.Position,
+		IsCover:    p.IsCover,
+		Width:      p.Width,
+		Height:     p.Height,
+		Url:        s.storage.URL(photos.OriginalKey(p.ApartmentID, p.ID, p.Extension)),
+		Thumbnails: thumbs,
+	}
+}
+
+func (s *InnerServer) photoResponses(ps []models.ApartmentPhoto) []dtos.PhotoResponse {
+	response := []dtos.PhotoResponse{}
+	for i := range ps {
+		response = append(response, s.photoResponse(&ps[i]))
+	}
+	return response
+}
+
+// removePhotoObjects удаляет оригинал и миниатюры; ошибки только логируются
+func (s *InnerServer) removePhotoObjects(ctx context.Context, p *models.ApartmentPhoto) {
+	keys := []string{photos.OriginalKey(p.ApartmentID, p.ID, p.Extension)}
+	for _, size := range photos.ThumbnailSizes {
+		keys = append(keys, photos.ThumbnailKey(p.ApartmentID, p.ID, size.Name))
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			logrus.WithField("Time", time.Now().String()).Warnf("failed to delete object %s: %v", key, err)
+		}
+	}
+}
+
+func (s *InnerServer) storePhotoObjects(ctx context.Context, p *models.ApartmentPhoto, processed *photos.Processed) error {
+	key := photos.OriginalKey(p.ApartmentID, p.ID, p.Extension)
+	if err := s.storage.Put(ctx, key, bytes.NewReader(processed.Original), int64(len(processed.Original)), processed.ContentType); err != nil {
+		return err
+	}
+
+	for name, data := range processed.Thumbnails {
+		key := photos.ThumbnailKey(p.ApartmentID, p.ID, name)
+		if err := s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// === PHOTOS ===
+
+// uploadApartmentPhotos принимает multipart-форму: owner_id и один или несколько файлов в поле photos
+func (s *InnerServer) uploadApartmentPhotos(c *gin.Context) {
+	apID := c.Param("id")
+	ownerID := c.PostForm("owner_id")
+
+	if _, err := uuid.Parse(ownerID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id must be a valid uuid"})
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid multipart form"})
+		return
+	}
+
+	files := form.File["photos"]
+	if len(files) == 0 || len(files) > maxPhotosPerUpload {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected between 1 and 20 files in 'photos'"})
+		return
+	}
+
+	if err := s.repository.CheckApartmentAccess(apID, ownerID, models.PermEditListing); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	processed := make([]*photos.Processed, 0, len(files))
+	for _, fh := range files {
+		if fh.Size > photos.MaxUploadSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file " + fh.Filename + " exceeds 10 MB"})
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file " + fh.Filename})
+			return
+		}
+
+		p, err := photos.Process(f)
+		_ = f.Close()
+		if err != nil {
+			if errors.Is(err, photos.ErrUnsupportedFormat) {
+				c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fh.Filename + ": " + err.Error()})
+				return
+			}
+			if errors.Is(err, photos.ErrTooManyPixels) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fh.Filename + ": " + err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": fh.Filename + ": " + err.Error()})
+			return
+		}
+		processed = append(processed, p)
+	}
+
+	ctx := c.Request.Context()
+	created := make([]models.ApartmentPhoto, 0, len(processed))
+	for _, p := range processed {
+		photo := models.ApartmentPhoto{
+			ID:          uuid.New().String(),
+			ApartmentID: apID,
+			Extension:   p.Extension,
+			ContentType: p.ContentType,
+			Width:       p.Width,
+			Height:      p.Height,
+		}
+
+		if err := s.storePhotoObjects(ctx, &photo, p); err != nil {
+			s.removePhotoObjects(ctx, &photo)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store photo", "uploaded": s.photoResponses(created)})
+			logrus.WithField("Time", time.Now().String()).Warnf("500: storage failure: %v", err)
+			return
+		}
+
+		if err := s.repository.AddApartmentPhoto(ownerID, &photo); err != nil {
+			s.removePhotoObjects(ctx, &photo)
+			writeError(c, err)
+			return
+		}
+
+		created = append(created, photo)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"photos": s.photoResponses(created)})
+	logrus.WithField("Time", time.Now().String()).Infof("201: Uploaded %d photos to apartment %s", len(created), apID)
+}
+
+func (s *InnerServer) getApartmentPhotos(c *gin.Context) {
+	ps, err := s.repository.GetApartmentPhotos(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(ps), "photos": s.photoResponses(ps)})
+}
+
+func (s *InnerServer) reorderApartmentPhotos(c *gin.Context) {
+	var dto dtos.PhotoOrderDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ps, err := s.repository.ReorderApartmentPhotos(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(ps), "photos": s.photoResponses(ps)})
+}
+
+func (s *InnerServer) setApartmentCover(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	if err := s.repository.SetApartmentCover(c.Param("id"), c.Param("photo_id"), dto.OwnerID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *InnerServer) deleteApartmentPhoto(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	photo, err := s.repository.DeleteApartmentPhoto(c.Param("id"), c.Param("photo_id"), dto.OwnerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	s.removePhotoObjects(c.Request.Context(), &photo)
+	c.Status(http.StatusNoContent)
+}
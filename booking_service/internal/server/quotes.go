@@ -0,0 +1,108 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"booking_service/internal/money"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// displayCurrency читает необязательный параметр currency; при ошибке уже отвечает 400
+func displayCurrency(c *gin.Context) (string, bool) {
+	currency := money.Normalize(c.Query("currency"))
+	if currency != "" && !money.IsSupported(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported currency '" + currency + "'"})
+		return "", false
+	}
+	return currency, true
+}
+
+// displayPrice — цена объявления в запрошенной валюте; без курса цена показывается только в своей
+func (s *InnerServer) displayPrice(ctx context.Context, ap *models.Apartment, currency string) *dtos.MoneyResponse {
+	if currency == "" {
+		return nil
+	}
+
+	amount, err := s.rates.Convert(ctx, ap.Price, ap.Currency, currency)
+	if err != nil {
+		logrus.WithField("Time", time.Now().String()).Warnf("price conversion %s -> %s failed: %v", ap.Currency, currency, err)
+		return nil
+	}
+	return &dtos.MoneyResponse{Amount: amount, Currency: currency}
+}
+
+func lineItemResponses(lines []models.BookingLineItem) []dtos.LineItemResponse {
+	response := make([]dtos.LineItemResponse, 0, len(lines))
+	for _, l := range lines {
+		response = append(response, dtos.LineItemResponse{
+			Kind:        l.Kind,
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitAmount:  l.UnitAmount,
+			Amount:      l.Amount,
+		})
+	}
+	return response
+}
+
+// === QUOTES ===
+
+// getQuote считает стоимость проживания со сборами и налогом города, не создавая брони.
+// Example: GET /apartments/:id/quote?check_in=2025-07-01&check_out=2025-07-05&adults=2&children=1&currency=USD
+// или точными моментами: ?time_from=2025-07-01T14:00:00Z&time_to=2025-07-05T11:00:00Z
+func (s *InnerServer) getQuote(c *gin.Context) {
+	var q dtos.QuoteQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "check_in/check_out must be YYYY-MM-DD, time_from/time_to RFC 3339 timestamps; guest counts within limits"})
+		return
+	}
+
+	currency, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
+
+	quote, err := s.repository.QuoteStay(c.Param("id"), q.StayDTO, q.GuestsDTO)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	loc := models.LoadZone(quote.TimeZone)
+	response := dtos.QuoteResponse{
+		ApartmentID:   quote.ApartmentID,
+		TimeFrom:      quote.TimeFrom.In(loc),
+		TimeTo:        quote.TimeTo.In(loc),
+		CheckIn:       quote.TimeFrom.In(loc).Format(time.DateOnly),
+		CheckOut:      quote.TimeTo.In(loc).Format(time.DateOnly),
+		TimeZone:      quote.TimeZone,
+		Nights:        quote.Nights,
+		Guests:        guestsResponse(quote.Guests),
+		Currency:      quote.Currency,
+		PricePerNight: quote.PricePerNight,
+		Lines:         lineItemResponses(quote.Lines),
+		Total:         quote.Total,
+	}
+
+	if currency != "" {
+		ctx := c.Request.Context()
+		rate, err := s.rates.Rate(ctx, quote.Currency, currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no exchange rate for " + quote.Currency + " -> " + currency})
+			return
+		}
+		response.Display = &dtos.QuoteDisplay{
+			Currency:      currency,
+			Rate:          rate,
+			PricePerNight: money.Round(quote.PricePerNight.Mul(rate), currency),
+			Total:         money.Round(quote.Total.Mul(rate), currency),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func reviewResponse(r *models.Review) dtos.ReviewResponse {
+	response := dtos.ReviewResponse{
+		Id:          r.ID,
+		BookingID:   r.BookingID,
+		Kind:        r.Kind,
+		AuthorID:    r.AuthorID,
+		SubjectID:   r.SubjectID,
+		Overall:     r.Overall,
+		Comment:     r.Comment,
+		CreatedAt:   r.CreatedAt,
+		PublishedAt: r.PublishedAt,
+	}
+	if categories := r.CategoryMap(); len(categories) > 0 {
+		response.Categories = categories
+	}
+	return response
+}
+
+// === REVIEWS ===
+
+// postReview — отзыв гостя об апартаменте или хоста о госте; кто есть кто, определяется по брони
+func (s *InnerServer) postReview(c *gin.Context) {
+	var dto dtos.ReviewCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	review, err := s.repository.AddReview(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reviewResponse(&review))
+	logrus.WithField("Time", time.Now().String()).Infof("201: Review %s for booking %s", review.ID, review.BookingID)
+}
+
+func (s *InnerServer) getApartmentReviews(c *gin.Context) {
+	s.listReviews(c, s.repository.GetApartmentReviews)
+}
+
+func (s *InnerServer) getGuestReviews(c *gin.Context) {
+	s.listReviews(c, s.repository.GetGuestReviews)
+}
+
+func (s *InnerServer) listReviews(c *gin.Context, fetch func(string, dtos.PageRequest) (*[]models.Review, dtos.PageInfo, error)) {
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
+		return
+	}
+
+	reviews, pageInfo, err := fetch(c.Param("id"), page)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.ReviewResponse{}
+	for i := range *reviews {
+		response = append(response, reviewResponse(&(*reviews)[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"reviews":     response,
+		"next_cursor": pageInfo.NextCursor,
+	})
+}
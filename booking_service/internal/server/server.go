@@ -1,15 +1,26 @@
 package server
 
 import (
+	"booking_service/internal/amenities"
+	"booking_service/internal/config"
 	"booking_service/internal/dtos"
+	"booking_service/internal/fees"
+	"booking_service/internal/geocoding"
+	"booking_service/internal/models"
+	"booking_service/internal/money"
+	"booking_service/internal/payments"
 	"booking_service/internal/repository"
 	servererrors "booking_service/internal/server_errors"
+	"booking_service/internal/storage"
+	"booking_service/internal/stream"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,26 +31,114 @@ import (
 type InnerServer struct {
 	router     *gin.Engine
 	repository repository.Repository
+	geocoder   geocoding.Geocoder
+	storage    storage.Storage
+	// attachments — приватное хранилище вложений переписки, наружу только через getThreadAttachment
+	attachments storage.Storage
+	broker      *stream.Broker
+	payments    *payments.Processor
+	rates       *money.Converter
 }
 
-func NewServer(db *gorm.DB) *InnerServer {
+func NewServer(db *gorm.DB, cfg *config.Config, store, attachments storage.Storage, broker *stream.Broker, processor *payments.Processor, rates *money.Converter, schedule *fees.Schedule) *InnerServer {
 	router := gin.Default()
+	router.MaxMultipartMemory = 32 << 20
 	s := &InnerServer{
-		router:     router,
-		repository: repository.NewRepository(db),
+		router:      router,
+		repository:  repository.NewRepository(db, schedule),
+		geocoder:    geocoding.NewOfflineGeocoder(),
+		storage:     store,
+		attachments: attachments,
+		broker:      broker,
+		payments:    processor,
+		rates:       rates,
 	}
 	s.routes()
+
+	// локальное хранилище раздаётся самим сервисом, S3 — по своим URL. Вложения переписки,
+	// оставшиеся в MEDIA_DIR со старых версий, наружу не отдаются — только через getThreadAttachment
+	if cfg.STORAGE_DRIVER == "local" {
+		s.router.Group("/media", hidePrivateMedia).Static("/", cfg.MEDIA_DIR)
+	}
+
 	return s
 }
 
+func hidePrivateMedia(c *gin.Context) {
+	if strings.HasPrefix(path.Clean("/"+c.Param("filepath")), "/messages/") {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Next()
+}
+
 func (s *InnerServer) routes() {
 	s.router.POST("/apartments", s.postApartment)
 	s.router.PATCH("/apartments/:id", s.updateApartment)
+	s.router.PUT("/apartments/:id/status", s.changeApartmentStatus)
+	s.router.DELETE("/apartments/:id", s.archiveApartment)
 	s.router.GET("/apartments", s.getApartmentsFiltered)
 	s.router.GET("/apartments/:id", s.getApartmentById)
+	s.router.GET("/apartments/:id/history", s.getApartmentHistory)
+	s.router.GET("/apartments/:id/quote", s.getQuote)
+	s.router.GET("/apartments/:id/stay-rules", s.getStayRules)
+	s.router.PUT("/apartments/:id/stay-rules", s.updateStayRules)
+	s.router.POST("/apartments/:id/photos", s.uploadApartmentPhotos)
+	s.router.GET("/apartments/:id/photos", s.getApartmentPhotos)
+	s.router.PUT("/apartments/:id/photos/order", s.reorderApartmentPhotos)
+	s.router.PUT("/apartments/:id/photos/:photo_id/cover", s.setApartmentCover)
+	s.router.DELETE("/apartments/:id/photos/:photo_id", s.deleteApartmentPhoto)
+	s.router.GET("/apartments/:id/bookings", s.getApartmentBookings)
+	s.router.GET("/apartments/:id/calendar/feed", s.getCalendarFeedLink)
+	s.router.POST("/apartments/:id/calendar/feed/rotate", s.rotateCalendarFeed)
+	s.router.POST("/apartments/:id/calendar/imports", s.postCalendarImport)
+	s.router.GET("/apartments/:id/calendar/imports", s.getCalendarImports)
+	s.router.DELETE("/apartments/:id/calendar/imports/:import_id", s.deleteCalendarImport)
+	s.router.GET("/apartments/:id/calendar/conflicts", s.getCalendarConflicts)
+	s.router.GET("/calendars/:feed", s.getCalendarFeed)
+	s.router.GET("/apartments/:id/cohosts", s.getCoHosts)
+	s.router.PUT("/apartments/:id/cohosts/:user_id", s.setCoHost)
+	s.router.DELETE("/apartments/:id/cohosts/:user_id", s.removeCoHost)
+	s.router.POST("/apartments/:id/transfers", s.initiateTransfer)
+	s.router.POST("/transfers/:id/accept", s.resolveTransfer(models.TransferAccepted))
+	s.router.POST("/transfers/:id/decline", s.resolveTransfer(models.TransferDeclined))
+	s.router.POST("/transfers/:id/cancel", s.resolveTransfer(models.TransferCancelled))
 	s.router.POST("/book", s.bookApartment)
+	s.router.POST("/bookings/:id/cancel", s.cancelBooking)
+	s.router.GET("/bookings/:id/payment", s.getBookingPayment)
+	s.router.GET("/bookings/:id/invoice", s.getInvoice)
+	s.router.POST("/payments/webhooks/:provider", s.handlePaymentWebhook)
+	s.router.GET("/bookings/:id/thread", s.getBookingThread)
+	s.router.POST("/bookings/:id/reviews", s.postReview)
+	s.router.GET("/apartments/:id/reviews", s.getApartmentReviews)
+	s.router.POST("/apartments/:id/inquiries", s.startInquiry)
+	s.router.GET("/users/:id/threads", s.getThreadsByUser)
+	s.router.GET("/threads/:id/messages", s.getThreadMessages)
+	s.router.POST("/threads/:id/messages", s.postMessage)
+	s.router.GET("/threads/:id/attachments/:attachment_id", s.getThreadAttachment)
+	s.router.POST("/threads/:id/read", s.markThreadRead)
 	s.router.GET("/owners/:id/apartments", s.getApartmentsByOwner)
+	s.router.POST("/owners/:id/apartments/import", s.importApartments)
+	s.router.GET("/owners/:id/apartments/export", s.exportApartments)
+	s.router.GET("/owners/:id/stream", s.streamOwnerEvents)
+	s.router.GET("/owners/:id/balance", s.getOwnerBalance)
+	s.router.GET("/owners/:id/payouts", s.getOwnerPayouts)
 	s.router.GET("/users/:id/bookings", s.getBookingsByUser)
+	s.router.GET("/users/:id/transfers", s.getTransfersByUser)
+	s.router.GET("/users/:id/reviews", s.getGuestReviews)
+	s.router.GET("/users/:id/notifications", s.getNotifications)
+	s.router.GET("/users/:id/notification-preferences", s.getNotificationPreferences)
+	s.router.PUT("/users/:id/notification-preferences", s.updateNotificationPreferences)
+	s.router.GET("/users/:id/billing-profile", s.getBillingProfile)
+	s.router.PUT("/users/:id/billing-profile", s.updateBillingProfile)
+	s.router.POST("/notifications/:id/read", s.markNotificationRead)
+	s.router.POST("/webhooks", s.createWebhook)
+	s.router.GET("/owners/:id/webhooks", s.getWebhooksByOwner)
+	s.router.PATCH("/webhooks/:id", s.updateWebhook)
+	s.router.DELETE("/webhooks/:id", s.deleteWebhook)
+	s.router.GET("/webhooks/:id/deliveries", s.getWebhookDeliveries)
+	s.router.POST("/webhooks/:id/deliveries/:delivery_id/replay", s.replayWebhookDelivery)
+	s.router.GET("/amenities", getAmenities)
 	s.router.GET("/health", health)
 }
 
@@ -49,6 +148,8 @@ func (s *InnerServer) Run(host, port string) *http.Server {
 		Addr:    addr,
 		Handler: s.router,
 	}
+	// открытые SSE-потоки иначе не дадут Shutdown завершиться
+	http_server.RegisterOnShutdown(s.broker.Close)
 
 	go func() {
 		logrus.WithField("Time", time.Now().String()).Infof("Server started on addr %s", addr)
@@ -76,8 +177,107 @@ func health(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+func getAmenities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"amenities": amenities.Catalogue()})
+}
+
 // === POST APARTMENT ===
 
+// resolveLocation дополняет адрес недостающей формой (строкой или структурой) и координатами
+func (s *InnerServer) resolveLocation(ctx context.Context, dto *dtos.ApartmentCreateDTO) {
+	if dto.Location == nil {
+		addr := geocoding.ParseAddress(dto.Address)
+		dto.Location = &dtos.AddressDTO{
+			Country:    addr.Country,
+			City:       addr.City,
+			Street:     addr.Street,
+			PostalCode: addr.PostalCode,
+		}
+	}
+
+	addr := geocoding.Address{
+		Country:    dto.Location.Country,
+		City:       dto.Location.City,
+		Street:     dto.Location.Street,
+		PostalCode: dto.Location.PostalCode,
+	}
+
+	if dto.Address == "" {
+		dto.Address = addr.String()
+	}
+	if dto.TimeZone == "" {
+		dto.TimeZone = geocoding.CityTimeZone(addr.City)
+	}
+
+	if dto.Location.Lat != nil && dto.Location.Lng != nil {
+		return
+	}
+
+	point, err := s.geocoder.Geocode(ctx, addr)
+	if err != nil {
+		logrus.WithField("Time", time.Now().String()).Warnf("geocoding failed for '%s': %v", dto.Address, err)
+		return
+	}
+
+	dto.Location.Lat = &point.Lat
+	dto.Location.Lng = &point.Lng
+}
+
+// parseAsOf читает необязательный параметр as_of (RFC 3339); при ошибке уже отвечает 400
+func parseAsOf(c *gin.Context) (*time.Time, bool) {
+	raw, ok := c.GetQuery("as_of")
+	if !ok {
+		return nil, true
+	}
+
+	asOf, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be an RFC 3339 timestamp"})
+		return nil, false
+	}
+
+	return &asOf, true
+}
+
+// descriptionInfo собирает info для ответа: свободные ключи описания плюс rooms и beds
+func descriptionInfo(desc *models.Description) map[string]string {
+	info := map[string]string{}
+
+	var tmp map[string]string
+	if err := json.Unmarshal([]byte(desc.Description), &tmp); err != nil {
+		logrus.WithField("Time", time.Now().String()).
+			Warnf("invalid JSON description string for ap_id=%s: %v", desc.ApartmentID, err)
+	} else if tmp != nil {
+		info = tmp
+	}
+
+	if desc.Rooms > 0 {
+		info["rooms"] = strconv.Itoa(desc.Rooms)
+	}
+
+	if desc.Beds >= 0 {
+		info["beds"] = strconv.Itoa(desc.Beds)
+	}
+
+	return info
+}
+
+func locationResponse(ap *models.Apartment) dtos.LocationResponse {
+	return dtos.LocationResponse{
+		Country:    ap.Country,
+		City:       ap.City,
+		Street:     ap.Street,
+		PostalCode: ap.PostalCode,
+		Lat:        ap.Lat,
+		Lng:        ap.Lng,
+		TimeZone:   ap.TimeZone,
+	}
+}
+
+func ratingResponse(ap *models.Apartment) dtos.RatingResponse {
+	return dtos.RatingResponse{Average: ap.RatingAvg, Count: ap.RatingCount}
+}
+
 func (s *InnerServer) postApartment(c *gin.Context) {
 	var dto dtos.ApartmentCreateDTO
 
@@ -87,6 +287,8 @@ func (s *InnerServer) postApartment(c *gin.Context) {
 		return
 	}
 
+	s.resolveLocation(c.Request.Context(), &dto)
+
 	ap, err := s.repository.AddApartment(&dto)
 	if err != nil {
 		var aee *servererrors.AlreadyExistsError
@@ -98,7 +300,7 @@ func (s *InnerServer) postApartment(c *gin.Context) {
 
 		var ve *servererrors.ValidationError
 		if errors.As(err, &ve) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request form, beds & rooms must be positive integers"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request form", "fields": ve.Fields})
 			logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
 			return
 		}
@@ -109,11 +311,21 @@ func (s *InnerServer) postApartment(c *gin.Context) {
 	}
 
 	response := dtos.MediumApartmentResponse{
-		Id:      ap.ID,
-		OwnerID: ap.OwnerID,
-		Address: ap.Address,
-		Price:   ap.Price,
-		Info:    dto.Info,
+		Id:          ap.ID,
+		OwnerID:     ap.OwnerID,
+		Address:     ap.Address,
+		Location:    locationResponse(&ap),
+		Price:       ap.Price,
+		Currency:    ap.Currency,
+		CleaningFee: ap.CleaningFee,
+		Status:      ap.Status,
+		Rating:      ratingResponse(&ap),
+		Info:        dto.Info,
+		Amenities:   map[string]any{},
+		Photos:      []dtos.PhotoResponse{},
+	}
+	if len(ap.Descriptions) > 0 {
+		response.Amenities = ap.Descriptions[0].AmenityMap()
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -130,23 +342,31 @@ func (s *InnerServer) updateApartment(c *gin.Context) {
 	}
 
 	var err error
-	if dto.Info != nil {
-		err = s.repository.UpdateApartmentHeavy(id, &dtos.ApartmentHeavyUpdateDTO{
-			OwnerID: dto.OwnerID,
-			Price:   dto.Price,
-			Info:    *dto.Info,
-		})
+	if dto.Info != nil || dto.Amenities != nil {
+		heavy := &dtos.ApartmentHeavyUpdateDTO{
+			OwnerID:     dto.OwnerID,
+			Price:       dto.Price,
+			CleaningFee: dto.CleaningFee,
+			TimeZone:    dto.TimeZone,
+			Amenities:   dto.Amenities,
+		}
+		if dto.Info != nil {
+			heavy.Info = *dto.Info
+		}
+		err = s.repository.UpdateApartmentHeavy(id, heavy)
 	} else {
 		err = s.repository.UpdateApartmentLight(id, &dtos.ApartmentLightUpdateDTO{
-			OwnerID: dto.OwnerID,
-			Price:   dto.Price,
+			OwnerID:     dto.OwnerID,
+			Price:       dto.Price,
+			CleaningFee: dto.CleaningFee,
+			TimeZone:    dto.TimeZone,
 		})
 	}
 
 	if err != nil {
 		var ve *servererrors.ValidationError
 		if errors.As(err, &ve) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request form, beds & rooms must be positive integers"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request form", "fields": ve.Fields})
 			logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
 			return
 		}
@@ -158,13 +378,7 @@ func (s *InnerServer) updateApartment(c *gin.Context) {
 			return
 		}
 
-		var nfe *servererrors.NotFoundError
-		if errors.As(err, &nfe) {
-			logrus.WithField("Time", time.Now().String()).Warnf("Could not find apartment with id %s", id)
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		logrus.WithField("Time", time.Now().String()).Warn("500: Internal Server Error")
+		writeError(c, err)
 		return
 	}
 
@@ -172,14 +386,29 @@ func (s *InnerServer) updateApartment(c *gin.Context) {
 }
 
 func (s *InnerServer) getApartmentsFiltered(c *gin.Context) {
-	// Example: GET /apartments?city=Budapest&rooms=2&beds=1
+	// Example: GET /apartments?city=Budapest&rooms=2&beds=1&amenities=wifi,view:sea
+	// Map view: GET /apartments?lat=47.49&lng=19.04&radius_km=3 or ?bbox=47.4,18.9,47.6,19.2
+	// Full-text: GET /apartments?q=sea view balcony (ranked by relevance)
+	// Best rated first: GET /apartments?city=Budapest&sort=rating
+	// Prices in another currency: GET /apartments?city=Budapest&currency=USD
+	// Room for a family: GET /apartments?city=Budapest&guests=4
 
 	allowed := map[string]bool{
-		"city":   true,
-		"rooms":  true,
-		"beds":   true,
-		"limit":  false,
-		"offset": false,
+		"q":         true,
+		"city":      true,
+		"rooms":     true,
+		"beds":      true,
+		"guests":    true,
+		"amenities": true,
+		"lat":       true,
+		"lng":       true,
+		"radius_km": true,
+		"bbox":      true,
+		"sort":      true,
+		"currency":  true,
+		"as_of":     true,
+		"limit":     true,
+		"cursor":    true,
 	}
 
 	for key := range c.Request.URL.Query() {
@@ -191,6 +420,22 @@ func (s *InnerServer) getApartmentsFiltered(c *gin.Context) {
 		}
 	}
 
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	currency, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
+
 	city := c.Query("city")
 	rooms := c.Query("rooms")
 	beds := c.Query("beds")
@@ -205,9 +450,31 @@ func (s *InnerServer) getApartmentsFiltered(c *gin.Context) {
 	if beds != "" {
 		filter["beds"] = beds
 	}
+	if guests := c.Query("guests"); guests != "" {
+		if n, err := strconv.Atoi(guests); err != nil || n < 1 || n > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "guests must be an integer between 1 and 50"})
+			return
+		}
+		filter["guests"] = guests
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		filter["q"] = q
+	}
+	for _, key := range []string{"amenities", "lat", "lng", "radius_km", "bbox", "sort"} {
+		if v, ok := c.GetQuery(key); ok {
+			filter[key] = v
+		}
+	}
 
-	aps, err := s.repository.GetApartments(filter)
+	aps, pageInfo, err := s.repository.GetApartments(filter, asOf, page)
 	if err != nil {
+		var bre *servererrors.BadRequestError
+		if errors.As(err, &bre) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bre.Violation})
+			logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch apartments"})
 		logrus.WithField("Time", time.Now().String()).Warn("500: Internal Server Error")
 		return
@@ -216,23 +483,39 @@ func (s *InnerServer) getApartmentsFiltered(c *gin.Context) {
 	response := []dtos.ShortApartmentResponse{}
 	for _, ap := range *aps {
 		response = append(response, dtos.ShortApartmentResponse{
-			Id:      ap.ID,
-			OwnerID: ap.OwnerID,
-			Address: ap.Address,
-			Price:   ap.Price,
+			Id:       ap.ID,
+			OwnerID:  ap.OwnerID,
+			Address:  ap.Address,
+			Location: locationResponse(&ap),
+			Price:    ap.Price,
+			Currency: ap.Currency,
+			Display:  s.displayPrice(c.Request.Context(), &ap, currency),
+			Status:   ap.Status,
+			Rating:   ratingResponse(&ap),
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"count":      len(response),
-		"apartments": response,
+		"count":       len(response),
+		"apartments":  response,
+		"next_cursor": pageInfo.NextCursor,
 	})
 }
 
 func (s *InnerServer) getApartmentById(c *gin.Context) {
 	id := c.Param("id")
 
-	ap, brs, err := s.repository.GetApartment(id)
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	currency, ok := displayCurrency(c)
+	if !ok {
+		return
+	}
+
+	ap, brs, err := s.repository.GetApartment(id, asOf)
 	if err != nil {
 		var nfe *servererrors.NotFoundError
 		if errors.As(err, &nfe) {
@@ -247,30 +530,26 @@ func (s *InnerServer) getApartmentById(c *gin.Context) {
 	}
 
 	info := map[string]string{}
+	amenityValues := map[string]any{}
 	if len(ap.Descriptions) > 0 {
-		var tmp map[string]string
-		if err := json.Unmarshal([]byte(ap.Descriptions[0].Description), &tmp); err != nil {
-			logrus.WithField("Time", time.Now().String()).
-				Warnf("invalid JSON description string for ap_id=%s: %v", id, err)
-		} else if tmp != nil {
-			info = tmp
-		}
-
-		if ap.Descriptions[0].Rooms > 0 {
-			info["rooms"] = strconv.Itoa(ap.Descriptions[0].Rooms)
-		}
-
-		if ap.Descriptions[0].Beds >= 0 {
-			info["beds"] = strconv.Itoa(ap.Descriptions[0].Beds)
-		}
+		info = descriptionInfo(&ap.Descriptions[0])
+		amenityValues = ap.Descriptions[0].AmenityMap()
 	}
 
 	response := dtos.MediumApartmentResponse{
-		Id:      ap.ID,
-		OwnerID: ap.OwnerID,
-		Address: ap.Address,
-		Price:   ap.Price,
-		Info:    info,
+		Id:          ap.ID,
+		OwnerID:     ap.OwnerID,
+		Address:     ap.Address,
+		Location:    locationResponse(&ap),
+		Price:       ap.Price,
+		Currency:    ap.Currency,
+		CleaningFee: ap.CleaningFee,
+		Display:     s.displayPrice(c.Request.Context(), &ap, currency),
+		Status:      ap.Status,
+		Rating:      ratingResponse(&ap),
+		Info:        info,
+		Amenities:   amenityValues,
+		Photos:      s.photoResponses(ap.Photos),
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -286,7 +565,7 @@ func (s *InnerServer) bookApartment(c *gin.Context) {
 		return
 	}
 
-	booking, err := s.repository.CreateBooking(&dto)
+	booking, res, err := s.payments.Book(c.Request.Context(), &dto)
 	if err != nil {
 		var oe *servererrors.OverlapError
 		if errors.As(err, &oe) {
@@ -295,35 +574,68 @@ func (s *InnerServer) bookApartment(c *gin.Context) {
 			return
 		}
 
-		var nfe *servererrors.NotFoundError
-		if errors.As(err, &nfe) {
-			logrus.WithField("Time", time.Now().String()).Warnf("Could not find apartment with id %s", dto.ApartmentID)
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		logrus.WithField("Time", time.Now().String()).Warn("500: Internal Server Error")
+		writeError(c, err)
 		return
 	}
 
 	logrus.WithField("Time", time.Now().String()).
-		WithFields(logrus.Fields{"id": booking.ID, "time_from": booking.TimeFrom, "time_to": booking.TimeTo}).
-		Infof("user %s booked apartment %s", booking.UserID, booking.UserID)
+		WithFields(logrus.Fields{"id": booking.ID, "time_from": booking.TimeFrom, "time_to": booking.TimeTo, "status": booking.Status}).
+		Infof("user %s booked apartment %s", booking.UserID, booking.ApartmentID)
+
+	// 202 — оплата ещё не авторизована, 402 — отклонена
+	code := http.StatusOK
+	switch booking.Status {
+	case models.BookingPendingPayment:
+		code = http.StatusAccepted
+	case models.BookingPaymentFailed:
+		code = http.StatusPaymentRequired
+	}
 
-	c.JSON(http.StatusOK, dtos.BookingResponse{
+	payment := paymentResponse(booking.Payment)
+	payment.ClientSecret = res.ClientSecret
+	c.JSON(code, dtos.BookingResponse{
 		Id:          booking.ID,
 		ApartmentID: booking.ApartmentID,
 		Address:     booking.Apartment.Address,
 		UserID:      booking.UserID,
 		TimeFrom:    booking.TimeFrom,
 		TimeTo:      booking.TimeTo,
+		CheckIn:     dateString(booking.CheckIn),
+		CheckOut:    dateString(booking.CheckOut),
+		Status:      booking.Status,
+		Guests:      guestsResponse(booking.Guests),
+		Payment:     payment,
+		LineItems:   lineItemResponses(booking.LineItems),
 	})
 }
 
 func (s *InnerServer) getApartmentsByOwner(c *gin.Context) {
 	id := c.Param("id")
 
-	aps, err := s.repository.GetApartmentsByOwner(id)
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.StatusDraft, models.StatusPublished, models.StatusUnlisted, models.StatusArchived:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown status filter"})
+		return
+	}
+
+	aps, pageInfo, err := s.repository.GetApartmentsByOwner(id, status, page)
 
 	if err != nil {
+		var bre *servererrors.BadRequestError
+		if errors.As(err, &bre) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bre.Violation})
+			logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		logrus.WithField("Time", time.Now().String()).Warn("500: Internal Server Error")
 		return
@@ -332,22 +644,10 @@ func (s *InnerServer) getApartmentsByOwner(c *gin.Context) {
 	response := []dtos.FullApartmentResponse{}
 	for _, ap := range *aps {
 		info := map[string]string{}
+		amenityValues := map[string]any{}
 		if len(ap.Descriptions) > 0 {
-			var tmp map[string]string
-			if err := json.Unmarshal([]byte(ap.Descriptions[0].Description), &tmp); err != nil {
-				logrus.WithField("Time", time.Now().String()).
-					Warnf("invalid JSON description string for ap_id=%s: %v", id, err)
-			} else if tmp != nil {
-				info = tmp
-			}
-
-			if ap.Descriptions[0].Rooms > 0 {
-				info["rooms"] = strconv.Itoa(ap.Descriptions[0].Rooms)
-			}
-
-			if ap.Descriptions[0].Beds >= 0 {
-				info["beds"] = strconv.Itoa(ap.Descriptions[0].Beds)
-			}
+			info = descriptionInfo(&ap.Descriptions[0])
+			amenityValues = ap.Descriptions[0].AmenityMap()
 		}
 
 		bookings := []dtos.ShortBookingResponse{}
@@ -361,24 +661,48 @@ func (s *InnerServer) getApartmentsByOwner(c *gin.Context) {
 		}
 
 		response = append(response, dtos.FullApartmentResponse{
-			Id:       ap.ID,
-			Address:  ap.Address,
-			OwnerID:  ap.OwnerID,
-			Price:    ap.Price,
-			Info:     info,
-			Bookings: bookings,
+			Id:        ap.ID,
+			Address:   ap.Address,
+			Location:  locationResponse(&ap),
+			OwnerID:   ap.OwnerID,
+			Price:     ap.Price,
+			Currency:  ap.Currency,
+			Status:    ap.Status,
+			Rating:    ratingResponse(&ap),
+			Info:      info,
+			Amenities: amenityValues,
+			Photos:    s.photoResponses(ap.Photos),
+			Bookings:  bookings,
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"count": len(response), "apartments": response})
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"apartments":  response,
+		"next_cursor": pageInfo.NextCursor,
+	})
 }
 
 func (s *InnerServer) getBookingsByUser(c *gin.Context) {
 	id := c.Param("id")
 
-	bs, err := s.repository.GetBookingsByUser(id)
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	bs, pageInfo, err := s.repository.GetBookingsByUser(id, page)
 
 	if err != nil {
+		var bre *servererrors.BadRequestError
+		if errors.As(err, &bre) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bre.Violation})
+			logrus.WithField("Time", time.Now().String()).Info("400: Bad Request")
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		logrus.WithField("Time", time.Now().String()).Warn("500: Internal Server Error")
 		return
@@ -393,8 +717,17 @@ func (s *InnerServer) getBookingsByUser(c *gin.Context) {
 			UserID:      booking.UserID,
 			TimeFrom:    booking.TimeFrom,
 			TimeTo:      booking.TimeTo,
+			CheckIn:     dateString(booking.CheckIn),
+			CheckOut:    dateString(booking.CheckOut),
+			Status:      booking.Status,
+			Guests:      guestsResponse(booking.Guests),
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"count": len(response), "bookings": response})
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"bookings":    response,
+		"next_cursor": pageInfo.NextCursor,
+	})
 }
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// === LISTING STATUS ===
+
+func (s *InnerServer) changeApartmentStatus(c *gin.Context) {
+	var dto dtos.ApartmentStatusDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	ap, err := s.repository.ChangeApartmentStatus(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": ap.ID, "status": ap.Status, "archived_at": ap.ArchivedAt})
+	logrus.WithField("Time", time.Now().String()).Infof("200: Apartment %s status set to %s", ap.ID, ap.Status)
+}
+
+// archiveApartment — DELETE /apartments/:id, мягкое удаление через статус archived
+func (s *InnerServer) archiveApartment(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	_, err := s.repository.ChangeApartmentStatus(c.Param("id"), &dtos.ApartmentStatusDTO{
+		OwnerID: dto.OwnerID,
+		Status:  models.StatusArchived,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
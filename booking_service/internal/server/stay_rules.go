@@ -0,0 +1,52 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func stayRulesResponse(r *models.StayRules) dtos.StayRulesResponse {
+	return dtos.StayRulesResponse{
+		ApartmentID:    r.ApartmentID,
+		MinNights:      r.MinNights,
+		MaxNights:      r.MaxNights,
+		CheckInDays:    models.WeekdayNames(r.CheckInDays),
+		CheckOutDays:   models.WeekdayNames(r.CheckOutDays),
+		CheckInTime:    r.CheckInTime,
+		CheckOutTime:   r.CheckOutTime,
+		MinNoticeHours: r.MinNoticeHours,
+		MaxHorizonDays: r.MaxHorizonDays,
+	}
+}
+
+// === STAY RULES ===
+
+func (s *InnerServer) getStayRules(c *gin.Context) {
+	rules, err := s.repository.GetStayRules(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stayRulesResponse(&rules))
+}
+
+// updateStayRules — PUT /apartments/:id/stay-rules; нужно право edit_listing
+func (s *InnerServer) updateStayRules(c *gin.Context) {
+	var dto dtos.StayRulesDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	rules, err := s.repository.UpdateStayRules(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stayRulesResponse(&rules))
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	streamReplayBatch = 500
+	// streamHeartbeat — ещё и интервал опроса outbox: так поток получает события, которые
+	// релей другого экземпляра опубликовал без общей шины
+	streamHeartbeat = 15 * time.Second
+)
+
+func writeSSE(w gin.ResponseWriter, e events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, body); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+func outboxEvent(ev *models.OutboxEvent) events.Event {
+	return events.Event{
+		ID:          ev.ID,
+		Seq:         *ev.PublishSeq,
+		Type:        ev.Type,
+		AggregateID: ev.AggregateID,
+		OccurredAt:  ev.CreatedAt,
+		Payload:     json.RawMessage(ev.Payload),
+	}
+}
+
+// === STREAM ===
+
+// streamOwnerEvents — GET /owners/:id/stream, Server-Sent Events по апартаментам владельца:
+// брони, отмены, смена статуса и владельца. После обрыва клиент переподключается с заголовком
+// Last-Event-ID (или ?last_event_id=) и получает пропущенные события из outbox.
+// Поток не аутентифицирован, как и остальной API: владелец задаётся id в пути, а id владельца
+// виден в карточках апартаментов. Наружу его можно отдавать только через шлюз, который
+// сам проверяет, что :id — это вызывающий пользователь
+func (s *InnerServer) streamOwnerEvents(c *gin.Context) {
+	ownerID := c.Param("id")
+	if _, err := uuid.Parse(ownerID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a valid uuid"})
+		return
+	}
+
+	lastRaw := c.GetHeader("Last-Event-ID")
+	if lastRaw == "" {
+		lastRaw = c.Query("last_event_id")
+	}
+	var last int64
+	if lastRaw != "" {
+		var err error
+		if last, err = strconv.ParseInt(lastRaw, 10, 64); err != nil || last < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "last event id must be a non-negative integer"})
+			return
+		}
+	}
+
+	// подписываемся до чтения из outbox, чтобы не пропустить события между ними
+	sub := s.broker.Subscribe(ownerID)
+	defer s.broker.Unsubscribe(sub)
+
+	// новый поток начинается с текущего момента, а не со всей истории
+	if lastRaw == "" {
+		var err error
+		if last, err = s.repository.GetLatestPublishSeq(); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	w.Flush()
+
+	// события всегда читаются из outbox по номеру публикации: брокер может получить их не
+	// по порядку (релеи нескольких экземпляров), а чужой экземпляр без шины — не получить вовсе
+	catchUp := func() error {
+		for {
+			missed, err := s.repository.GetOwnerEventsSince(ownerID, last, streamReplayBatch)
+			if err != nil {
+				return err
+			}
+			for i := range missed {
+				if err := writeSSE(w, outboxEvent(&missed[i])); err != nil {
+					return err
+				}
+				last = *missed[i].PublishSeq
+			}
+			if len(missed) < streamReplayBatch {
+				return nil
+			}
+		}
+	}
+	if err := catchUp(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			return
+		case <-sub.Events():
+			// событие брокера — только сигнал; накопившиеся сигналы читаются одним запросом
+			for drained := false; !drained; {
+				select {
+				case <-sub.Events():
+				default:
+					drained = true
+				}
+			}
+			if err := catchUp(); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			w.Flush()
+			if err := catchUp(); err != nil {
+				return
+			}
+		}
+	}
+}
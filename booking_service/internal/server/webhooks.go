@@ -0,0 +1,161 @@
+package server
+
+import (
+	"booking_service/internal/dtos"
+	"booking_service/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+func webhookResponse(w *models.WebhookSubscription) dtos.WebhookResponse {
+	return dtos.WebhookResponse{
+		Id:                  w.ID,
+		OwnerID:             w.OwnerID,
+		URL:                 w.URL,
+		EventTypes:          w.EventTypeList(),
+		Active:              w.Active,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		DisabledAt:          w.DisabledAt,
+		CreatedAt:           w.CreatedAt,
+	}
+}
+
+func deliveryResponse(d *models.WebhookDelivery) dtos.WebhookDeliveryResponse {
+	response := dtos.WebhookDeliveryResponse{
+		Id:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == models.DeliveryPending {
+		next := d.NextAttemptAt
+		response.NextAttemptAt = &next
+	}
+	return response
+}
+
+// === WEBHOOKS ===
+
+func (s *InnerServer) createWebhook(c *gin.Context) {
+	var dto dtos.WebhookCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	w, err := s.repository.CreateWebhook(&dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := webhookResponse(&w)
+	response.Secret = w.Secret
+
+	c.JSON(http.StatusCreated, response)
+	logrus.WithField("Time", time.Now().String()).Infof("201: Webhook %s created", w.ID)
+}
+
+func (s *InnerServer) getWebhooksByOwner(c *gin.Context) {
+	ws, err := s.repository.GetWebhooksByOwner(c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.WebhookResponse{}
+	for i := range ws {
+		response = append(response, webhookResponse(&ws[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": len(response), "webhooks": response})
+}
+
+func (s *InnerServer) updateWebhook(c *gin.Context) {
+	var dto dtos.WebhookUpdateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	w, err := s.repository.UpdateWebhook(c.Param("id"), &dto)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookResponse(&w))
+}
+
+func (s *InnerServer) deleteWebhook(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	if err := s.repository.DeleteWebhook(c.Param("id"), dto.OwnerID); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// getWebhookDeliveries — GET /webhooks/:id/deliveries?owner_id=...&limit=&cursor=
+func (s *InnerServer) getWebhookDeliveries(c *gin.Context) {
+	ownerID := c.Query("owner_id")
+	if _, err := uuid.Parse(ownerID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id must be a valid uuid"})
+		return
+	}
+
+	var page dtos.PageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+		return
+	}
+
+	ds, pageInfo, err := s.repository.GetWebhookDeliveries(c.Param("id"), ownerID, page)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	response := []dtos.WebhookDeliveryResponse{}
+	for i := range *ds {
+		response = append(response, deliveryResponse(&(*ds)[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(response),
+		"total":       pageInfo.Total,
+		"deliveries":  response,
+		"next_cursor": pageInfo.NextCursor,
+	})
+}
+
+func (s *InnerServer) replayWebhookDelivery(c *gin.Context) {
+	var dto dtos.OwnerActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	d, err := s.repository.ReplayWebhookDelivery(c.Param("id"), c.Param("delivery_id"), dto.OwnerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, deliveryResponse(&d))
+}
@@ -40,8 +40,8 @@ func (e *ForbiddenAccessError) Error() string {
 // ===============================================================================
 
 type FieldError struct {
-	Field   string
-	Message string
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type ValidationError struct {
@@ -75,4 +75,15 @@ type OverlapError struct {
 
 func (e *OverlapError) Error() string {
 	return fmt.Sprintf("Booking time overlap on apartment %s", e.ApId)
-}
\ No newline at end of file
+}
+// ===============================================================================
+
+type StateConflictError struct {
+	Entity string
+	Key    string
+	Reason string
+}
+
+func (e *StateConflictError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Entity, e.Key, e.Reason)
+}
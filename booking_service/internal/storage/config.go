@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"booking_service/internal/config"
+	"context"
+	"fmt"
+)
+
+// NewFromConfig выбирает реализацию хранилища по BOOKING_STORAGE_DRIVER
+func NewFromConfig(ctx context.Context, cfg *config.Config) (Storage, error) {
+	switch cfg.STORAGE_DRIVER {
+	case "local":
+		return NewLocalStorage(cfg.MEDIA_DIR, cfg.MEDIA_BASE_URL)
+	case "s3":
+		return NewS3Storage(ctx, S3Config{
+			Endpoint:  cfg.S3_ENDPOINT,
+			AccessKey: cfg.S3_ACCESS_KEY,
+			SecretKey: cfg.S3_SECRET_KEY,
+			Bucket:    cfg.S3_BUCKET,
+			Region:    cfg.S3_REGION,
+			UseSSL:    cfg.S3_USE_SSL,
+			PublicURL: cfg.S3_PUBLIC_URL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver '%s'", cfg.STORAGE_DRIVER)
+	}
+}
+
+// NewPrivateFromConfig — хранилище для вложений переписки: отдельный каталог вне MEDIA_DIR
+// или отдельный бакет без публичного доступа; объекты отдаёт только сервер после проверки прав
+func NewPrivateFromConfig(ctx context.Context, cfg *config.Config) (Storage, error) {
+	switch cfg.STORAGE_DRIVER {
+	case "local":
+		return NewLocalStorage(cfg.PRIVATE_MEDIA_DIR, "")
+	case "s3":
+		return NewS3Storage(ctx, S3Config{
+			Endpoint:  cfg.S3_ENDPOINT,
+			AccessKey: cfg.S3_ACCESS_KEY,
+			SecretKey: cfg.S3_SECRET_KEY,
+			Bucket:    cfg.S3_PRIVATE_BUCKET,
+			Region:    cfg.S3_REGION,
+			UseSSL:    cfg.S3_USE_SSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver '%s'", cfg.STORAGE_DRIVER)
+	}
+}
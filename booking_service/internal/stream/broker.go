@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"booking_service/internal/events"
+	"context"
+	"sync"
+)
+
+// BufferSize — сколько событий может ждать медленный клиент; при переполнении
+// подписка закрывается, и клиент догоняет пропущенное через Last-Event-ID
+const BufferSize = 64
+
+// Subscriber — одно открытое SSE-соединение пользователя
+type Subscriber struct {
+	userID string
+	ch     chan events.Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (s *Subscriber) Events() <-chan events.Event {
+	return s.ch
+}
+
+// Done закрывается, когда брокер отключил подписчика (переполнение или остановка сервиса)
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Broker раздаёт опубликованные события открытым потокам владельцев; поток использует их
+// как сигнал и читает сами события из outbox. События приходят из NATS (все экземпляры)
+// или от релея своего экземпляра. Реализует events.Publisher и никогда не блокирует отправителя
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[string]map[*Subscriber]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[string]map[*Subscriber]struct{}{}}
+}
+
+func (b *Broker) Subscribe(userID string) *Subscriber {
+	s := &Subscriber{userID: userID, ch: make(chan events.Event, BufferSize), done: make(chan struct{})}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		s.close()
+		return s
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = map[*Subscriber]struct{}{}
+	}
+	b.subs[userID][s] = struct{}{}
+	return s
+}
+
+func (b *Broker) Unsubscribe(s *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(s)
+}
+
+func (b *Broker) remove(s *Subscriber) {
+	if set := b.subs[s.userID]; set != nil {
+		delete(set, s)
+		if len(set) == 0 {
+			delete(b.subs, s.userID)
+		}
+	}
+	s.close()
+}
+
+func (b *Broker) Publish(_ context.Context, e events.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, userID := range events.OwnerIDs(e.Payload) {
+		for s := range b.subs[userID] {
+			select {
+			case s.ch <- e:
+			default:
+				b.remove(s)
+			}
+		}
+	}
+	return nil
+}
+
+// Close отключает всех подписчиков; вызывается при остановке HTTP-сервера
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, set := range b.subs {
+		for s := range set {
+			s.close()
+		}
+	}
+	b.subs = map[string]map[*Subscriber]struct{}{}
+}
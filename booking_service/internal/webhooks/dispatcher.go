@@ -0,0 +1,227 @@
+package webhooks
+
+import (
+	"booking_service/internal/models"
+	"booking_service/internal/safehttp"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxAttempts — после стольких неудач доставка помечается failed; её можно повторить вручную
+	MaxAttempts = 10
+	// DisableAfter — столько неудачных попыток подряд отключают endpoint
+	DisableAfter = 25
+
+	batchSize   = 20
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+
+	// claimLease — на сколько пачка закрепляется за диспетчером; больше, чем batchSize таймаутов клиента
+	claimLease = 5 * time.Minute
+)
+
+// Dispatcher отправляет доставки из webhook_deliveries на endpoint'ы подписчиков.
+// Клиент не соединяется с непубличными адресами, даже если DNS подписчика поменялся
+type Dispatcher struct {
+	db       *gorm.DB
+	client   *http.Client
+	interval time.Duration
+}
+
+func NewDispatcher(db *gorm.DB, interval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		db:       db,
+		client:   safehttp.NewClient(10 * time.Second),
+		interval: interval,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := d.dispatchBatch(ctx)
+			if err != nil {
+				logrus.WithError(err).Warn("Webhook dispatch failed")
+				break
+			}
+			if n < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim забирает пачку просроченных доставок активных подписок и сдвигает их next_attempt_at
+// на claimLease: другие экземпляры её не возьмут, а при падении доставки вернутся в очередь
+func (d *Dispatcher) claim(ctx context.Context) ([]models.WebhookDelivery, error) {
+	// TRANSACTION [BEGIN]
+	tx := d.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	var batch []models.WebhookDelivery
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "webhook_deliveries"}, Options: "SKIP LOCKED"}).
+		Joins("Subscription").
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Where(`"Subscription".active`).
+		Order("webhook_deliveries.next_attempt_at").
+		Limit(batchSize).
+		Find(&batch).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(batch) == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	ids := make([]string, len(batch))
+	for i := range batch {
+		ids[i] = batch[i].ID
+	}
+	if err := tx.Model(&models.WebhookDelivery{}).
+		Where("delivery_id IN ?", ids).
+		Update("next_attempt_at", time.Now().Add(claimLease)).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// COMMIT (TRANSACTION END)
+	return batch, tx.Commit().Error
+}
+
+// dispatchBatch отправляет пачку и возвращает её размер. HTTP-запросы идут вне транзакции,
+// результат каждой доставки записывается своей короткой транзакцией
+func (d *Dispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	batch, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range batch {
+		dl := &batch[i]
+		code, sendErr := d.send(ctx, &dl.Subscription, dl)
+		if err := d.record(ctx, dl, code, sendErr); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(batch), nil
+}
+
+// record сохраняет результат доставки и счётчик неудач подписки
+func (d *Dispatcher) record(ctx context.Context, dl *models.WebhookDelivery, code *int, sendErr error) error {
+	// TRANSACTION [BEGIN]
+	tx := d.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := d.apply(tx, dl, code, sendErr); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// COMMIT (TRANSACTION END)
+	return tx.Commit().Error
+}
+
+func (d *Dispatcher) apply(tx *gorm.DB, dl *models.WebhookDelivery, code *int, sendErr error) error {
+	// подписку перечитываем под блокировкой: в пачке может быть несколько её доставок
+	sub := &dl.Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("webhook_id = ?", dl.SubscriptionID).First(sub).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dl.Attempts++
+	dl.LastStatusCode = code
+
+	if sendErr == nil {
+		dl.Status = models.DeliverySucceeded
+		dl.DeliveredAt = &now
+		dl.LastError = ""
+		if err := tx.Model(dl).Select("status", "attempts", "last_status_code", "last_error", "delivered_at").Updates(dl).Error; err != nil {
+			return err
+		}
+		return tx.Model(sub).Update("consecutive_failures", 0).Error
+	}
+
+	dl.LastError = sendErr.Error()
+	if dl.Attempts >= MaxAttempts {
+		dl.Status = models.DeliveryFailed
+	} else {
+		dl.NextAttemptAt = now.Add(backoff(dl.Attempts))
+	}
+	if err := tx.Model(dl).Select("status", "attempts", "last_status_code", "last_error", "next_attempt_at").Updates(dl).Error; err != nil {
+		return err
+	}
+
+	sub.ConsecutiveFailures++
+	updates := map[string]any{"consecutive_failures": sub.ConsecutiveFailures}
+	if sub.ConsecutiveFailures >= DisableAfter && sub.Active {
+		updates["active"] = false
+		updates["disabled_at"] = now
+		logrus.WithField("Time", now.String()).Warnf("Webhook %s disabled after %d consecutive failures", sub.ID, sub.ConsecutiveFailures)
+	}
+	return tx.Model(sub).Updates(updates).Error
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, dl *models.WebhookDelivery) (*int, error) {
+	body := []byte(dl.Body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "booking-service-webhooks/1")
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, time.Now().Unix(), body))
+	req.Header.Set(EventIDHeader, dl.EventID)
+	req.Header.Set(EventTypeHeader, dl.EventType)
+	req.Header.Set(DeliveryHeader, dl.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	code := resp.StatusCode
+	if code < 200 || code >= 300 {
+		return &code, fmt.Errorf("endpoint responded with %d", code)
+	}
+	return &code, nil
+}
+
+// backoff: 30s, 1m, 2m ... но не больше maxBackoff
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
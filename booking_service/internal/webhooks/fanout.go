@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"booking_service/internal/events"
+	"booking_service/internal/models"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Fanout — events.Publisher, который раскладывает событие по подпискам владельца
+// в очередь webhook_deliveries. Сама отправка — в Dispatcher
+type Fanout struct {
+	db *gorm.DB
+}
+
+func NewFanout(db *gorm.DB) *Fanout {
+	return &Fanout{db: db}
+}
+
+func (f *Fanout) Publish(ctx context.Context, e events.Event) error {
+	owners := events.OwnerIDs(e.Payload)
+	if len(owners) == 0 {
+		return nil
+	}
+
+	var subs []models.WebhookSubscription
+	if err := f.db.WithContext(ctx).
+		Where("active AND owner_id IN ? AND event_types @> to_jsonb(?::text)", owners, e.Type).
+		Find(&subs).Error; err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(subs))
+	for _, s := range subs {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: s.ID,
+			EventID:        e.ID,
+			EventType:      e.Type,
+			Body:           string(body),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+
+	// релей может прислать событие повторно — доставка на endpoint остаётся одна
+	return f.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&deliveries).Error
+}